@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+)
+
+type Op string
+
+const (
+	OpRead  Op = "read"
+	OpWrite Op = "write"
+	// OpAdmin changes the cluster, such as its view or namespaces. Only
+	// admins may do it.
+	OpAdmin Op = "admin"
+)
+
+// Wildcard matches any principal or namespace in a Rule.
+const Wildcard = "*"
+
+// Rule allows a principal some operations on the keys of a namespace that
+// start with a prefix.
+type Rule struct {
+	Principal string `json:"principal"`
+	Namespace string `json:"namespace"`
+	Prefix    string `json:"prefix"`
+	Ops       []Op   `json:"ops"`
+}
+
+func (r Rule) allows(principal string, op Op, namespace, key string) bool {
+	return (r.Principal == Wildcard || r.Principal == principal) &&
+		(r.Namespace == Wildcard || r.Namespace == namespace) &&
+		strings.HasPrefix(key, r.Prefix) &&
+		slices.Contains(r.Ops, op)
+}
+
+// ACL maps principals to what they may do. Anything not allowed by a rule is
+// denied. Admins may do anything.
+type ACL struct {
+	Admins []string `json:"admins"`
+	Rules  []Rule   `json:"rules"`
+}
+
+// Admin reports whether principal is an admin.
+func (a *ACL) Admin(principal string) bool {
+	return slices.Contains(a.Admins, principal)
+}
+
+// Allowed reports whether principal may do op on a key in namespace.
+func (a *ACL) Allowed(principal string, op Op, namespace, key string) bool {
+	if a.Admin(principal) {
+		return true
+	}
+	if op == OpAdmin {
+		return false
+	}
+	for _, rule := range a.Rules {
+		if rule.allows(principal, op, namespace, key) {
+			return true
+		}
+	}
+	return false
+}
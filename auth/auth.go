@@ -0,0 +1,42 @@
+// Package auth authenticates requests to okayv and authorizes them against
+// an ACL of key prefixes.
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator for a request that
+	// does not carry its kind of credentials.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalid is returned for credentials that are present but wrong.
+	ErrInvalid = errors.New("invalid credentials")
+)
+
+// Authenticator returns the principal that made a request. The body is
+// passed separately since the request body may already have been read.
+type Authenticator interface {
+	Authenticate(r *http.Request, body []byte) (string, error)
+}
+
+// Signer adds credentials to a request with the given body.
+type Signer interface {
+	Sign(r *http.Request, body []byte) error
+}
+
+// Any authenticates a request with the first authenticator that finds its
+// kind of credentials in it.
+type Any []Authenticator
+
+func (a Any) Authenticate(r *http.Request, body []byte) (string, error) {
+	for _, auth := range a {
+		principal, err := auth.Authenticate(r, body)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return "", ErrNoCredentials
+}
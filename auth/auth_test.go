@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newRequest(t *testing.T, body string) *http.Request {
+	t.Helper()
+	r, err := http.NewRequest(http.MethodPut, "http://node/write?namespace=x", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestHMAC(t *testing.T) {
+	key := HMACKey{ID: "alice", Secret: []byte("secret")}
+	verifier := &HMAC{Keys: map[string][]byte{"alice": []byte("secret")}}
+
+	r := newRequest(t, `{"key":"k"}`)
+	if err := key.Sign(r, []byte(`{"key":"k"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if principal, err := verifier.Authenticate(r, []byte(`{"key":"k"}`)); err != nil || principal != "alice" {
+		t.Errorf("Authenticate = %q, %v, wanted alice", principal, err)
+	}
+	if _, err := verifier.Authenticate(r, []byte(`{"key":"other"}`)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Authenticate of a changed body = %v, wanted %v", err, ErrInvalid)
+	}
+	if _, err := verifier.Authenticate(r, []byte(`{"key":"k"}`)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Authenticate of a replayed request = %v, wanted %v", err, ErrInvalid)
+	}
+
+	r = newRequest(t, `{"key":"k"}`)
+	if err := key.Sign(r, []byte(`{"key":"k"}`)); err != nil {
+		t.Fatal(err)
+	}
+	other := r.Clone(r.Context())
+	other.Host = "other"
+	if _, err := verifier.Authenticate(other, []byte(`{"key":"k"}`)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Authenticate of a request sent to another node = %v, wanted %v", err, ErrInvalid)
+	}
+	late := &HMAC{Keys: verifier.Keys, Now: func() time.Time { return time.Now().Add(time.Hour) }}
+	if _, err := late.Authenticate(r, []byte(`{"key":"k"}`)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Authenticate of an old signature = %v, wanted %v", err, ErrInvalid)
+	}
+
+	wrong := HMACKey{ID: "alice", Secret: []byte("guess")}
+	r = newRequest(t, "")
+	if err := wrong.Sign(r, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Authenticate(r, nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Authenticate with the wrong secret = %v, wanted %v", err, ErrInvalid)
+	}
+}
+
+func TestAny(t *testing.T) {
+	authn := Any{
+		Tokens{"t0k3n": "bob"},
+		&HMAC{Keys: map[string][]byte{"alice": []byte("secret")}},
+	}
+	for _, tc := range []struct {
+		name   string
+		signer Signer
+		want   string
+		err    error
+	}{
+		{"bearer", BearerToken("t0k3n"), "bob", nil},
+		{"bad bearer", BearerToken("guess"), "", ErrInvalid},
+		{"hmac", HMACKey{ID: "alice", Secret: []byte("secret")}, "alice", nil},
+		{"anonymous", nil, "", ErrNoCredentials},
+	} {
+		r := newRequest(t, "")
+		if tc.signer != nil {
+			if err := tc.signer.Sign(r, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		principal, err := authn.Authenticate(r, nil)
+		if principal != tc.want || !errors.Is(err, tc.err) {
+			t.Errorf("%s: Authenticate = %q, %v, wanted %q, %v", tc.name, principal, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestACL(t *testing.T) {
+	acl := &ACL{
+		Admins: []string{"root"},
+		Rules: []Rule{
+			{Principal: "alice", Namespace: Wildcard, Prefix: "alice/", Ops: []Op{OpRead, OpWrite}},
+			{Principal: Wildcard, Namespace: "docs", Prefix: "", Ops: []Op{OpRead}},
+		},
+	}
+	for _, tc := range []struct {
+		principal string
+		op        Op
+		namespace string
+		key       string
+		want      bool
+	}{
+		{"alice", OpWrite, "", "alice/x", true},
+		{"alice", OpWrite, "other", "alice/x", true},
+		{"alice", OpWrite, "", "bob/x", false},
+		{"bob", OpRead, "docs", "readme", true},
+		{"bob", OpWrite, "docs", "readme", false},
+		{"alice", OpAdmin, "", "", false},
+		{"root", OpAdmin, "", "", true},
+		{"root", OpWrite, "", "bob/x", true},
+	} {
+		if got := acl.Allowed(tc.principal, tc.op, tc.namespace, tc.key); got != tc.want {
+			t.Errorf("Allowed(%s, %s, %q, %q) = %v, wanted %v", tc.principal, tc.op, tc.namespace, tc.key, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+// Tokens authenticates bearer tokens. It maps each token to its principal.
+type Tokens map[string]string
+
+func (t Tokens) Authenticate(r *http.Request, _ []byte) (string, error) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, bearerPrefix)
+	if !ok {
+		return "", ErrNoCredentials
+	}
+	// Compare against every token so that the time taken does not depend on
+	// which one matched.
+	principal := ""
+	for candidate, p := range t {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			principal = p
+		}
+	}
+	if principal == "" {
+		return "", ErrInvalid
+	}
+	return principal, nil
+}
+
+// BearerToken signs requests with a bearer token.
+type BearerToken string
+
+func (b BearerToken) Sign(r *http.Request, _ []byte) error {
+	r.Header.Set("Authorization", bearerPrefix+string(b))
+	return nil
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Config is the authentication of a node as read from a file.
+type Config struct {
+	// Tokens maps bearer tokens to their principals.
+	Tokens map[string]string `json:"tokens"`
+	// Keys maps HMAC key IDs to their secrets.
+	Keys map[string]string `json:"keys"`
+	// Peer is the key ID in Keys that the node signs requests to its peers
+	// with. It should be an admin.
+	Peer string `json:"peer"`
+	ACL  *ACL   `json:"acl"`
+}
+
+// LoadConfig reads a JSON Config.
+func LoadConfig(file string) (*Config, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(buf, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if _, ok := c.Keys[c.Peer]; c.Peer != "" && !ok {
+		return nil, fmt.Errorf("%s: no key for peer %q", file, c.Peer)
+	}
+	return &c, nil
+}
+
+// Authenticator accepts the tokens and keys of c.
+func (c *Config) Authenticator() Authenticator {
+	keys := make(map[string][]byte, len(c.Keys))
+	for id, secret := range c.Keys {
+		keys[id] = []byte(secret)
+	}
+	return Any{Tokens(c.Tokens), &HMAC{Keys: keys}}
+}
+
+// PeerSigner signs with the peer key, or is nil without one.
+func (c *Config) PeerSigner() Signer {
+	if c.Peer == "" {
+		return nil
+	}
+	return HMACKey{ID: c.Peer, Secret: []byte(c.Keys[c.Peer])}
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HMACScheme is the scheme of the Authorization header of HMAC signed
+	// requests, as in "OKV-HMAC-SHA256 Credential=<key id>, Signature=<hex>".
+	HMACScheme = "OKV-HMAC-SHA256"
+	// DateHeader holds the RFC 3339 time a request was signed at.
+	DateHeader = "X-Okv-Date"
+	// NonceHeader holds a random value that makes each signed request
+	// unique, so that it cannot be replayed.
+	NonceHeader = "X-Okv-Nonce"
+
+	// DefaultMaxSkew is how far the signing time of a request may be from
+	// the time it is checked.
+	DefaultMaxSkew = 5 * time.Minute
+)
+
+// HMAC authenticates requests signed with shared secrets. The principal is
+// the ID of the key. Each nonce is accepted once while its request is within
+// MaxSkew, so a signed request cannot be replayed.
+type HMAC struct {
+	Keys    map[string][]byte
+	MaxSkew time.Duration
+	// Now returns the current time. The default is time.Now.
+	Now func() time.Time
+
+	mu sync.Mutex
+	// seen holds the nonces accepted within the skew and their dates.
+	seen   map[string]time.Time
+	pruned time.Time
+}
+
+func (h *HMAC) Authenticate(r *http.Request, body []byte) (string, error) {
+	params, ok := strings.CutPrefix(r.Header.Get("Authorization"), HMACScheme+" ")
+	if !ok {
+		return "", ErrNoCredentials
+	}
+	var keyID, signature string
+	for _, param := range strings.Split(params, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		switch name {
+		case "Credential":
+			keyID = value
+		case "Signature":
+			signature = value
+		}
+	}
+	secret, ok := h.Keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown key %q", ErrInvalid, keyID)
+	}
+
+	date, err := time.Parse(time.RFC3339, r.Header.Get(DateHeader))
+	if err != nil {
+		return "", fmt.Errorf("%w: bad %s", ErrInvalid, DateHeader)
+	}
+	now, skew := time.Now, h.MaxSkew
+	if h.Now != nil {
+		now = h.Now
+	}
+	if skew == 0 {
+		skew = DefaultMaxSkew
+	}
+	if d := now().Sub(date); d > skew || d < -skew {
+		return "", fmt.Errorf("%w: signed at %s", ErrInvalid, date)
+	}
+
+	nonce := r.Header.Get(NonceHeader)
+	if nonce == "" {
+		return "", fmt.Errorf("%w: no %s", ErrInvalid, NonceHeader)
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(got, sign(secret, r, date, nonce, body)) {
+		return "", fmt.Errorf("%w: bad signature", ErrInvalid)
+	}
+	if !h.first(keyID+" "+nonce, date, now(), skew) {
+		return "", fmt.Errorf("%w: replayed nonce %s", ErrInvalid, nonce)
+	}
+	return keyID, nil
+}
+
+// first records a nonce and reports whether it had not been seen. Nonces
+// are forgotten once their date is outside the skew, when the date check
+// rejects them anyway.
+func (h *HMAC) first(nonce string, date, now time.Time, skew time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.seen == nil {
+		h.seen = make(map[string]time.Time)
+	}
+	if now.Sub(h.pruned) > skew {
+		for n, d := range h.seen {
+			if now.Sub(d) > skew {
+				delete(h.seen, n)
+			}
+		}
+		h.pruned = now
+	}
+	if _, ok := h.seen[nonce]; ok {
+		return false
+	}
+	h.seen[nonce] = date
+	return true
+}
+
+// HMACKey signs requests with a shared secret.
+type HMACKey struct {
+	ID     string
+	Secret []byte
+}
+
+func (k HMACKey) Sign(r *http.Request, body []byte) error {
+	date := time.Now().UTC().Truncate(time.Second)
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(buf[:])
+	r.Header.Set(DateHeader, date.Format(time.RFC3339))
+	r.Header.Set(NonceHeader, nonce)
+	r.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s, Signature=%x",
+		HMACScheme, k.ID, sign(k.Secret, r, date, nonce, body)))
+	return nil
+}
+
+// sign returns the signature of the method, host, URI, date, nonce and body
+// of r. The host keeps a request sent to one node from being replayed to
+// another, which has not seen its nonce.
+func sign(secret []byte, r *http.Request, date time.Time, nonce string, body []byte) []byte {
+	digest := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s\n%x", r.Method, r.Host, r.URL.RequestURI(), date.Format(time.RFC3339), nonce, digest)
+	return mac.Sum(nil)
+}
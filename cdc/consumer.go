@@ -0,0 +1,196 @@
+// Package cdc tails the change-data-capture log of a replica and re-emits it
+// as newline-delimited JSON.
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spencer-p/okayv/server"
+)
+
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Checkpoint persists the next offset to consume.
+type Checkpoint interface {
+	Load() (int, error)
+	Save(offset int) error
+}
+
+// FileCheckpoint stores the offset as text in a file.
+type FileCheckpoint string
+
+func (f FileCheckpoint) Load() (int, error) {
+	buf, err := os.ReadFile(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(buf)))
+}
+
+func (f FileCheckpoint) Save(offset int) error {
+	// Write and rename so a crash never leaves a torn checkpoint.
+	tmp, err := os.CreateTemp(filepath.Dir(string(f)), ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(tmp, "%d\n", offset); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), string(f))
+}
+
+// MemoryCheckpoint keeps the offset in memory only.
+type MemoryCheckpoint struct {
+	Offset int
+}
+
+func (m *MemoryCheckpoint) Load() (int, error) {
+	return m.Offset, nil
+}
+
+func (m *MemoryCheckpoint) Save(offset int) error {
+	m.Offset = offset
+	return nil
+}
+
+type Consumer struct {
+	agent      string
+	address    string
+	client     HTTPClient
+	checkpoint Checkpoint
+	out        io.Writer
+	batch      int
+	// namespace is the only namespace written if filter is set.
+	namespace string
+	filter    bool
+}
+
+func NewConsumer(c HTTPClient, agent, address string, cp Checkpoint, out io.Writer) *Consumer {
+	return &Consumer{
+		agent:      agent,
+		address:    address,
+		client:     c,
+		checkpoint: cp,
+		out:        out,
+		batch:      1000,
+	}
+}
+
+// SetNamespace writes only the changes to keys of namespace. The checkpoint
+// still advances past the others.
+func (c *Consumer) SetNamespace(namespace string) {
+	c.namespace = namespace
+	c.filter = true
+}
+
+// Poll fetches one batch of changes after the checkpoint, writes them to the
+// output and advances the checkpoint. It returns the number of records
+// read, including any of other namespaces that were not written. If the
+// replica no longer retains the checkpoint's offset, the error wraps
+// server.ErrTruncated.
+func (c *Consumer) Poll(ctx context.Context) (int, error) {
+	offset, err := c.checkpoint.Load()
+	if err != nil {
+		return 0, fmt.Errorf("load checkpoint: %w", err)
+	}
+
+	changes, err := c.fetch(ctx, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(c.out)
+	for _, change := range changes {
+		if change.Offset != offset {
+			return 0, fmt.Errorf("expected offset %d, got %d", offset, change.Offset)
+		}
+		offset++
+		if c.filter && change.Namespace != c.namespace {
+			continue
+		}
+		if err := enc.Encode(&change); err != nil {
+			return 0, err
+		}
+	}
+	if len(changes) == 0 {
+		return 0, nil
+	}
+	if err := c.checkpoint.Save(offset); err != nil {
+		return 0, fmt.Errorf("save checkpoint: %w", err)
+	}
+	return len(changes), nil
+}
+
+// Run polls until ctx is done. Full batches are followed immediately by
+// another poll; otherwise Run waits freq between polls.
+func (c *Consumer) Run(ctx context.Context, freq time.Duration) error {
+	for ctx.Err() == nil {
+		n, err := c.Poll(ctx)
+		if err != nil {
+			return err
+		}
+		if n >= c.batch {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(freq):
+		}
+	}
+	return ctx.Err()
+}
+
+func (c *Consumer) fetch(ctx context.Context, offset int) ([]server.Change, error) {
+	addr := fmt.Sprintf("%s/cdc?offset=%d&limit=%d", c.address, offset, c.batch)
+	httpreq, err := http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpreq.Header.Set("User-Agent", c.agent)
+	httpresp, err := c.client.Do(httpreq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpresp.Body.Close()
+	if httpresp.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("offset %d: first retained is %s: %w", offset, httpresp.Header.Get(server.FirstOffsetHeader), server.ErrTruncated)
+	}
+	if httpresp.StatusCode != http.StatusOK {
+		buf, _ := io.ReadAll(httpresp.Body)
+		return nil, fmt.Errorf("fetch changes failed with code %v: %s", httpresp.StatusCode, buf)
+	}
+
+	var changes []server.Change
+	dec := json.NewDecoder(httpresp.Body)
+	for {
+		var change server.Change
+		if err := dec.Decode(&change); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
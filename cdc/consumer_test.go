@@ -0,0 +1,143 @@
+package cdc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+type doFunc func(*http.Request) (*http.Response, error)
+
+func (f doFunc) Do(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestConsumer(t *testing.T) {
+	muxes := map[string]*http.ServeMux{}
+	do := doFunc(func(r *http.Request) (*http.Response, error) {
+		recorder := httptest.NewRecorder()
+		muxes[r.Host].ServeHTTP(recorder, r)
+		return recorder.Result(), nil
+	})
+	var servers []*server.Server
+	for _, name := range []string{"a", "b"} {
+		muxes[name] = http.NewServeMux()
+		servers = append(servers, server.NewServer(muxes[name], server.Opts{
+			Client:     do,
+			Name:       name,
+			GossipFreq: time.Second,
+		}))
+	}
+
+	c := client.NewClient(do, "alice", "http://a")
+	if err := c.Write("x", "1"); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := c.Write("y", "2"); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	viewChange := httptest.NewRequest(http.MethodPut, "http://a/view-change",
+		bytes.NewBufferString(`{"replicas": ["http://a", "http://b"]}`))
+	if resp, err := do.Do(viewChange); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("view change failed: %v %v", resp, err)
+	}
+	servers[0].Gossip()
+
+	var out bytes.Buffer
+	cp := &MemoryCheckpoint{}
+	consumer := NewConsumer(do, "test", "http://b", cp, &out)
+	n, err := consumer.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if n != 2 || cp.Offset != 2 {
+		t.Errorf("got %d records and offset %d, wanted 2 and 2", n, cp.Offset)
+	}
+
+	var got []server.Change
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var change server.Change
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			t.Fatalf("invalid record %q: %v", scanner.Text(), err)
+		}
+		got = append(got, change)
+	}
+	for i, change := range got {
+		if change.Kind != server.ChangeReplicate || change.Source != "a" || change.Replica != "b" {
+			t.Errorf("record %d = %+v, wanted a replicate from a on b", i, change)
+		}
+		if len(change.Replicated) != 2 {
+			t.Errorf("record %d replicated to %v, wanted a and b", i, change.Replicated)
+		}
+	}
+
+	// A second poll must not repeat anything.
+	out.Reset()
+	n, err = consumer.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if n != 0 || out.Len() != 0 {
+		t.Errorf("second poll wrote %d records: %q", n, out.String())
+	}
+}
+
+func TestConsumerTruncated(t *testing.T) {
+	mux := http.NewServeMux()
+	do := doFunc(func(r *http.Request) (*http.Response, error) {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, r)
+		return recorder.Result(), nil
+	})
+	server.NewServer(mux, server.Opts{
+		Client:          do,
+		Name:            "a",
+		GossipFreq:      time.Second,
+		ChangeRetention: 2,
+	})
+	c := client.NewClient(do, "alice", "http://a")
+	for _, key := range []string{"x", "y", "z"} {
+		if err := c.Write(key, "1"); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	cp := &MemoryCheckpoint{}
+	consumer := NewConsumer(do, "test", "http://a", cp, &out)
+	if _, err := consumer.Poll(context.Background()); !errors.Is(err, server.ErrTruncated) {
+		t.Errorf("poll from a dropped offset = %v, wanted %v", err, server.ErrTruncated)
+	}
+
+	cp.Offset = 1
+	n, err := consumer.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if n != 2 || cp.Offset != 3 {
+		t.Errorf("got %d records and offset %d, wanted 2 and 3", n, cp.Offset)
+	}
+	var change server.Change
+	if err := json.NewDecoder(&out).Decode(&change); err != nil {
+		t.Fatalf("invalid record: %v", err)
+	}
+	if change.Schema != server.ChangeSchema || change.Value != "1" || string(change.ValueBytes) != "1" {
+		t.Errorf("record = %+v, wanted schema %d and value 1", change, server.ChangeSchema)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := consumer.Run(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("run with a canceled context = %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Status is the status of a replica.
+type Status struct {
+	Name   string         `json:"name"`
+	Peers  []string       `json:"peers"`
+	View   []string       `json:"view"`
+	MaxCC  map[string]int `json:"maxcc"`
+	Events int            `json:"events"`
+	Keys   int            `json:"keys"`
+	Uptime string         `json:"uptime"`
+}
+
+// ViewChange installs a view of replicas, the URLs of every replica in the
+// cluster. The replica it is sent to forwards it to the others.
+func (c *Client) ViewChange(replicas []string) error {
+	return c.request(http.MethodPut, "/view-change", map[string]any{"replicas": replicas}, nil)
+}
+
+// Status returns the status of the replica.
+func (c *Client) Status() (Status, error) {
+	var status Status
+	err := c.request(http.MethodGet, "/status", nil, &status)
+	return status, err
+}
+
+// Dump returns the internal state of the replica as JSON, or only the parts
+// about key if it is not empty. Its format is that of the server's
+// /debug/state and may change.
+func (c *Client) Dump(key string) (json.RawMessage, error) {
+	var dump json.RawMessage
+	err := c.request(http.MethodGet, "/debug/state", map[string]any{"namespace": c.namespace, "key": key}, &dump)
+	return dump, err
+}
+
+// request sends in as JSON to path and decodes the reply into out unless it
+// is nil.
+func (c *Client) request(method, path string, in, out any) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	httpreq, err := http.NewRequest(method, c.address+path, &body)
+	if err != nil {
+		return err
+	}
+	httpresp, err := c.do(httpreq)
+	if err != nil {
+		return err
+	}
+	defer httpresp.Body.Close()
+	if httpresp.StatusCode == http.StatusServiceUnavailable {
+		return ErrUnavailable
+	} else if httpresp.StatusCode != http.StatusOK {
+		buf, _ := io.ReadAll(httpresp.Body)
+		return fmt.Errorf("%s failed with code %v: %s", path, httpresp.StatusCode, buf)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(httpresp.Body).Decode(out)
+}
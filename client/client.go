@@ -2,21 +2,32 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spencer-p/okayv/auth"
+	"go.opentelemetry.io/otel/propagation"
 )
 
+const contextHeader = "X-Causal-Context"
+
 type HTTPClient interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
 type Client struct {
-	agent   string
-	address string
-	context any
-	client  HTTPClient
+	agent     string
+	address   string
+	namespace string
+	context   any
+	client    HTTPClient
+	signer    auth.Signer
+	trace     context.Context
 }
 
 func NewClient(c HTTPClient, agent, address string) *Client {
@@ -31,11 +42,54 @@ func (c *Client) SetAddress(address string) {
 	c.address = address
 }
 
+// SetNamespace scopes all further requests to a namespace. The causal context
+// is shared across namespaces.
+func (c *Client) SetNamespace(namespace string) {
+	c.namespace = namespace
+}
+
+// SetSigner authenticates all further requests with signer.
+func (c *Client) SetSigner(signer auth.Signer) {
+	c.signer = signer
+}
+
+// SetTraceContext propagates the trace of ctx with all further requests, so
+// that the spans of the servers join it.
+func (c *Client) SetTraceContext(ctx context.Context) {
+	c.trace = ctx
+}
+
+// Context returns the client's current causal context. It can be passed to
+// ReadAt to read several keys at one causal cut.
+func (c *Client) Context() any {
+	return c.context
+}
+
+// SetContext replaces the client's causal context, such as with one saved
+// from Context by an earlier client.
+func (c *Client) SetContext(ctx any) {
+	c.context = ctx
+}
+
 func (c *Client) Read(key string) (string, error) {
+	return c.read(key, nil)
+}
+
+// ReadAt reads the value of key as it was at the causal cut at. The cut may
+// be the context of a client of any replica.
+func (c *Client) ReadAt(key string, at any) (string, error) {
+	return c.read(key, at)
+}
+
+func (c *Client) read(key string, at any) (string, error) {
 	var body bytes.Buffer
 	req := map[string]any{
 		"key":            key,
 		"causal-context": c.context,
+		"namespace":      c.namespace,
+	}
+	if at != nil {
+		req["at"] = at
 	}
 	if err := json.NewEncoder(&body).Encode(req); err != nil {
 		return "", err
@@ -45,18 +99,20 @@ func (c *Client) Read(key string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	httpreq.Header.Set("User-Agent", c.agent)
-	httpresp, err := c.client.Do(httpreq)
+	httpresp, err := c.do(httpreq)
 	if err != nil {
 		return "", err
 	}
 	defer httpresp.Body.Close()
-	var resp map[string]any
+	var resp struct {
+		Value   []byte `json:"value"`
+		Context any    `json:"causal-context"`
+	}
 	if err := json.NewDecoder(httpresp.Body).Decode(&resp); err != nil {
 		return "", err
 	}
-	if ctx, ok := resp["causal-context"]; ok {
-		c.context = ctx
+	if resp.Context != nil {
+		c.context = resp.Context
 	}
 
 	if httpresp.StatusCode == http.StatusNotFound {
@@ -72,15 +128,190 @@ func (c *Client) Read(key string) (string, error) {
 		return "", fmt.Errorf("read failed with code %v: %s", httpresp.StatusCode, errtext)
 	}
 
-	return resp["value"].(string), nil
+	return string(resp.Value), nil
+}
+
+// ReadMany reads several keys from one causal cut of the server. Keys that do
+// not exist are left out of the result.
+func (c *Client) ReadMany(keys ...string) (map[string]string, error) {
+	var body bytes.Buffer
+	req := map[string]any{
+		"keys":           keys,
+		"causal-context": c.context,
+		"namespace":      c.namespace,
+	}
+	if err := json.NewEncoder(&body).Encode(req); err != nil {
+		return nil, err
+	}
+
+	httpreq, err := http.NewRequest(http.MethodPost, c.address+"/read-many", &body)
+	if err != nil {
+		return nil, err
+	}
+	httpresp, err := c.do(httpreq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusServiceUnavailable {
+		return nil, ErrUnavailable
+	} else if httpresp.StatusCode != http.StatusOK {
+		buf, err := io.ReadAll(httpresp.Body)
+		errtext := string(buf)
+		if err != nil {
+			errtext = fmt.Sprintf("an error occurred reading the body: %s", err.Error())
+		}
+		return nil, fmt.Errorf("read many failed with code %v: %s", httpresp.StatusCode, errtext)
+	}
+
+	var resp struct {
+		Values  map[string][]byte `json:"values"`
+		Context any               `json:"causal-context"`
+	}
+	if err := json.NewDecoder(httpresp.Body).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Context != nil {
+		c.context = resp.Context
+	}
+	values := make(map[string]string, len(resp.Values))
+	for key, value := range resp.Values {
+		values[key] = string(value)
+	}
+	return values, nil
+}
+
+// Query returns the keys, with their values, whose JSON value has value at
+// the indexed path.
+func (c *Client) Query(path string, value any) (map[string]string, error) {
+	var body bytes.Buffer
+	req := map[string]any{
+		"path":           path,
+		"value":          value,
+		"causal-context": c.context,
+		"namespace":      c.namespace,
+	}
+	if err := json.NewEncoder(&body).Encode(req); err != nil {
+		return nil, err
+	}
+
+	httpreq, err := http.NewRequest(http.MethodPost, c.address+"/query", &body)
+	if err != nil {
+		return nil, err
+	}
+	httpresp, err := c.do(httpreq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusServiceUnavailable {
+		return nil, ErrUnavailable
+	} else if httpresp.StatusCode != http.StatusOK {
+		buf, err := io.ReadAll(httpresp.Body)
+		errtext := string(buf)
+		if err != nil {
+			errtext = fmt.Sprintf("an error occurred reading the body: %s", err.Error())
+		}
+		return nil, fmt.Errorf("query failed with code %v: %s", httpresp.StatusCode, errtext)
+	}
+
+	var resp struct {
+		Results []struct {
+			Key   string `json:"key"`
+			Value []byte `json:"value"`
+		} `json:"results"`
+		Context any `json:"causal-context"`
+	}
+	if err := json.NewDecoder(httpresp.Body).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Context != nil {
+		c.context = resp.Context
+	}
+	values := make(map[string]string, len(resp.Results))
+	for _, kv := range resp.Results {
+		values[kv.Key] = string(kv.Value)
+	}
+	return values, nil
+}
+
+// KeyValue is a key with its value.
+type KeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Scan returns the keys that start with prefix, in order, with their values.
+// It returns at most limit keys unless limit is zero.
+func (c *Client) Scan(prefix string, limit int) ([]KeyValue, error) {
+	var results []KeyValue
+	after := ""
+	for {
+		var body bytes.Buffer
+		req := map[string]any{
+			"prefix":         prefix,
+			"after":          after,
+			"causal-context": c.context,
+			"namespace":      c.namespace,
+		}
+		if limit > 0 {
+			req["limit"] = limit - len(results)
+		}
+		if err := json.NewEncoder(&body).Encode(req); err != nil {
+			return nil, err
+		}
+
+		httpreq, err := http.NewRequest(http.MethodPost, c.address+"/scan", &body)
+		if err != nil {
+			return nil, err
+		}
+		httpresp, err := c.do(httpreq)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			Results []struct {
+				Key   string `json:"key"`
+				Value []byte `json:"value"`
+			} `json:"results"`
+			More    bool `json:"more"`
+			Context any  `json:"causal-context"`
+		}
+		if httpresp.StatusCode == http.StatusServiceUnavailable {
+			httpresp.Body.Close()
+			return nil, ErrUnavailable
+		} else if httpresp.StatusCode != http.StatusOK {
+			buf, _ := io.ReadAll(httpresp.Body)
+			httpresp.Body.Close()
+			return nil, fmt.Errorf("scan failed with code %v: %s", httpresp.StatusCode, buf)
+		}
+		err = json.NewDecoder(httpresp.Body).Decode(&resp)
+		httpresp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.Context != nil {
+			c.context = resp.Context
+		}
+		for _, kv := range resp.Results {
+			results = append(results, KeyValue{Key: kv.Key, Value: string(kv.Value)})
+		}
+		if !resp.More || len(resp.Results) == 0 || (limit > 0 && len(results) >= limit) {
+			return results, nil
+		}
+		after = resp.Results[len(resp.Results)-1].Key
+	}
 }
 
 func (c *Client) Write(key, value string) error {
 	var body bytes.Buffer
 	req := map[string]any{
 		"key":            key,
-		"value":          value,
+		"value":          []byte(value),
 		"causal-context": c.context,
+		"namespace":      c.namespace,
 	}
 	if err := json.NewEncoder(&body).Encode(req); err != nil {
 		return err
@@ -90,8 +321,7 @@ func (c *Client) Write(key, value string) error {
 	if err != nil {
 		return err
 	}
-	httpreq.Header.Set("User-Agent", c.agent)
-	httpresp, err := c.client.Do(httpreq)
+	httpresp, err := c.do(httpreq)
 	if err != nil {
 		return err
 	}
@@ -114,9 +344,167 @@ func (c *Client) Write(key, value string) error {
 	}
 	c.context = resp["causal-context"]
 
+	if httpresp.StatusCode == http.StatusAccepted {
+		return ErrPending
+	}
+	return nil
+}
+
+// ReadBytes reads a value from the key's REST resource and returns it with
+// its content type.
+func (c *Client) ReadBytes(key string) ([]byte, string, error) {
+	httpreq, err := http.NewRequest(http.MethodGet, c.kvURL(key), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	httpresp, err := c.doRaw(httpreq)
+	if err != nil {
+		return nil, "", err
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNotFound
+	} else if httpresp.StatusCode == http.StatusServiceUnavailable {
+		return nil, "", ErrUnavailable
+	} else if httpresp.StatusCode != http.StatusOK {
+		buf, _ := io.ReadAll(httpresp.Body)
+		return nil, "", fmt.Errorf("read failed with code %v: %s", httpresp.StatusCode, buf)
+	}
+	value, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return value, httpresp.Header.Get("Content-Type"), nil
+}
+
+// WriteBytes writes a value to the key's REST resource. An empty content type
+// is stored as is and read back as application/octet-stream.
+func (c *Client) WriteBytes(key string, value []byte, contentType string) error {
+	httpreq, err := http.NewRequest(http.MethodPut, c.kvURL(key), bytes.NewReader(value))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		httpreq.Header.Set("Content-Type", contentType)
+	}
+	httpresp, err := c.doRaw(httpreq)
+	if err != nil {
+		return err
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusServiceUnavailable {
+		return ErrUnavailable
+	} else if httpresp.StatusCode == http.StatusRequestEntityTooLarge {
+		return ErrTooLarge
+	} else if httpresp.StatusCode < 200 || httpresp.StatusCode >= 300 {
+		buf, _ := io.ReadAll(httpresp.Body)
+		return fmt.Errorf("write failed with code %v: %s", httpresp.StatusCode, buf)
+	} else if httpresp.StatusCode == http.StatusAccepted {
+		return ErrPending
+	}
+	return nil
+}
+
+// Delete deletes a key. Deleting a key that does not exist returns
+// ErrNotFound.
+func (c *Client) Delete(key string) error {
+	httpreq, err := http.NewRequest(http.MethodDelete, c.kvURL(key), nil)
+	if err != nil {
+		return err
+	}
+	httpresp, err := c.doRaw(httpreq)
+	if err != nil {
+		return err
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	} else if httpresp.StatusCode == http.StatusServiceUnavailable {
+		return ErrUnavailable
+	} else if httpresp.StatusCode < 200 || httpresp.StatusCode >= 300 {
+		buf, _ := io.ReadAll(httpresp.Body)
+		return fmt.Errorf("delete failed with code %v: %s", httpresp.StatusCode, buf)
+	} else if httpresp.StatusCode == http.StatusAccepted {
+		return ErrPending
+	}
 	return nil
 }
 
+// kvURL returns the URL of a key's REST resource. It escapes every dot as
+// well, so the keys "." and ".." survive path cleaning.
+func (c *Client) kvURL(key string) string {
+	u := c.address + "/v1/kv/" + strings.ReplaceAll(url.PathEscape(key), ".", "%2E")
+	if c.namespace != "" {
+		u += "?" + url.Values{"namespace": {c.namespace}}.Encode()
+	}
+	return u
+}
+
+// doRaw sends a request whose body is a raw value, carrying the causal
+// context in a header in both directions.
+func (c *Client) doRaw(httpreq *http.Request) (*http.Response, error) {
+	if c.context != nil {
+		ctx, err := json.Marshal(c.context)
+		if err != nil {
+			return nil, err
+		}
+		httpreq.Header.Set(contextHeader, string(ctx))
+	}
+	httpresp, err := c.do(httpreq)
+	if err != nil {
+		return nil, err
+	}
+	if raw := httpresp.Header.Get(contextHeader); raw != "" {
+		var ctx any
+		if err := json.Unmarshal([]byte(raw), &ctx); err == nil {
+			c.context = ctx
+		}
+	}
+	return httpresp, nil
+}
+
+// do sends a request, signed by the client's signer if it has one. Requests
+// that fail authentication or authorization return an error.
+func (c *Client) do(httpreq *http.Request) (*http.Response, error) {
+	httpreq.Header.Set("User-Agent", c.agent)
+	if c.trace != nil {
+		propagation.TraceContext{}.Inject(c.trace, propagation.HeaderCarrier(httpreq.Header))
+	}
+	if c.signer != nil {
+		var body []byte
+		if httpreq.GetBody != nil {
+			rc, err := httpreq.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			body, err = io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := c.signer.Sign(httpreq, body); err != nil {
+			return nil, err
+		}
+	}
+	httpresp, err := c.client.Do(httpreq)
+	if err != nil {
+		return nil, err
+	}
+	switch httpresp.StatusCode {
+	case http.StatusUnauthorized:
+		httpresp.Body.Close()
+		return nil, ErrUnauthenticated
+	case http.StatusForbidden:
+		httpresp.Body.Close()
+		return nil, ErrForbidden
+	}
+	return httpresp, nil
+}
+
 func (c *Client) EventsWitnessed() int {
 	ctx, ok := c.context.(map[string]any)
 	if !ok {
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Counter types.
+const (
+	// GCounter can only be incremented.
+	GCounter = "g-counter"
+	// PNCounter can be incremented and decremented.
+	PNCounter = "pn-counter"
+)
+
+// Increment adds delta to a counter of either type, creating a PN-Counter if
+// needed.
+func (c *Client) Increment(key string, delta int64) error {
+	return c.op("/counter", map[string]any{
+		"key":   key,
+		"delta": delta,
+	})
+}
+
+// IncrementCounter adds delta to a counter of type typ, GCounter or
+// PNCounter, creating it if needed. It fails with ErrConflict if the key is
+// of another type.
+func (c *Client) IncrementCounter(key, typ string, delta int64) error {
+	return c.op("/counter", map[string]any{
+		"key":   key,
+		"type":  typ,
+		"delta": delta,
+	})
+}
+
+// Counter reads the value of a counter.
+func (c *Client) Counter(key string) (int64, error) {
+	value, err := c.Read(key)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	if err := json.Unmarshal([]byte(value), &n); err != nil {
+		return 0, fmt.Errorf("%s is not a counter: %w", key, err)
+	}
+	return n, nil
+}
+
+// SetAdd adds elements to an OR-Set, creating it if needed.
+func (c *Client) SetAdd(key string, elems ...string) error {
+	return c.op("/set", map[string]any{
+		"key": key,
+		"add": elems,
+	})
+}
+
+// SetRemove removes elements from an OR-Set. Adds of the same elements that
+// the server has not seen yet are kept.
+func (c *Client) SetRemove(key string, elems ...string) error {
+	return c.op("/set", map[string]any{
+		"key":    key,
+		"remove": elems,
+	})
+}
+
+// Members reads the sorted elements of an OR-Set.
+func (c *Client) Members(key string) ([]string, error) {
+	value, err := c.Read(key)
+	if err != nil {
+		return nil, err
+	}
+	var members []string
+	if err := json.Unmarshal([]byte(value), &members); err != nil {
+		return nil, fmt.Errorf("%s is not a set: %w", key, err)
+	}
+	return members, nil
+}
+
+// SetRegister writes the value of an LWW-Register, creating it if needed.
+// Registers are read with Read or ReadBytes.
+func (c *Client) SetRegister(key string, value []byte, contentType string) error {
+	return c.op("/register", map[string]any{
+		"key":          key,
+		"value":        value,
+		"content-type": contentType,
+	})
+}
+
+func (c *Client) op(path string, req map[string]any) error {
+	req["causal-context"] = c.context
+	req["namespace"] = c.namespace
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(req); err != nil {
+		return err
+	}
+
+	httpreq, err := http.NewRequest(http.MethodPost, c.address+path, &body)
+	if err != nil {
+		return err
+	}
+	httpresp, err := c.do(httpreq)
+	if err != nil {
+		return err
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusServiceUnavailable {
+		return ErrUnavailable
+	} else if httpresp.StatusCode == http.StatusConflict {
+		return ErrConflict
+	} else if httpresp.StatusCode == http.StatusRequestEntityTooLarge {
+		return ErrTooLarge
+	} else if httpresp.StatusCode < 200 || httpresp.StatusCode >= 300 {
+		buf, _ := io.ReadAll(httpresp.Body)
+		return fmt.Errorf("%s failed with code %v: %s", path, httpresp.StatusCode, buf)
+	}
+
+	var resp map[string]any
+	if err := json.NewDecoder(httpresp.Body).Decode(&resp); err != nil {
+		return err
+	}
+	c.context = resp["causal-context"]
+	if httpresp.StatusCode == http.StatusAccepted {
+		return ErrPending
+	}
+	return nil
+}
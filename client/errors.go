@@ -5,4 +5,14 @@ import "errors"
 var (
 	ErrNotFound    = errors.New("not found")
 	ErrUnavailable = errors.New("unavailable, try again")
+	ErrTooLarge    = errors.New("value too large")
+	ErrConflict    = errors.New("key holds another type")
+
+	// ErrPending is returned by writes that were applied, but reached fewer
+	// replicas than the namespace's replication factor. The causal context
+	// still reflects the write.
+	ErrPending = errors.New("written, but not yet replicated")
+
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("forbidden")
 )
@@ -0,0 +1,488 @@
+// Command okayctl reads and writes the keys of an okayv cluster and
+// administers it.
+//
+// The causal context of each command is kept in a session file, so that
+// consecutive commands see each other's writes. Each address has its own
+// context.
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spencer-p/okayv/auth"
+	"github.com/spencer-p/okayv/cdc"
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+const usage = `Usage: okayctl [flags] command [args]
+
+Commands:
+  get KEY                 print the value of KEY
+  put KEY VALUE           write VALUE, or standard input if it is -, to KEY
+  delete KEY              delete KEY
+  scan [-limit N] [PREFIX]
+                          list the keys starting with PREFIX and their values
+  watch [-from OFFSET]    print changes as they happen, or from OFFSET on;
+                          only those of -namespace if it is given
+  view-change URL...      install a view of the replicas at URL...
+  status                  print the status of the replica
+  dump [KEY]              print the internal state of the replica, or of KEY
+
+Flags:
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, http.DefaultClient); errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "okayctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// session is what okayctl remembers between invocations.
+type session struct {
+	Address string `json:"address"`
+	// Contexts holds the causal context of each address, so that the
+	// context of one cluster is not sent to another.
+	Contexts map[string]any `json:"causal-contexts,omitempty"`
+	// Context is the single context of older sessions, which belongs to
+	// Address.
+	Context any `json:"causal-context,omitempty"`
+}
+
+func defaultSession() string {
+	if file := os.Getenv("OKAYCTL_SESSION"); file != "" {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".okayctl-session.json")
+}
+
+func loadSession(file string) (session, error) {
+	var s session
+	if file == "" {
+		return s, nil
+	}
+	buf, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return s, fmt.Errorf("%s: %w", file, err)
+	}
+	if s.Context != nil {
+		if s.Contexts == nil {
+			s.Contexts = make(map[string]any)
+		}
+		s.Contexts[s.Address] = s.Context
+		s.Context = nil
+	}
+	return s, nil
+}
+
+// save writes the session to a temporary file first so that an interrupted
+// save keeps the previous session.
+func (s session) save(file string) error {
+	if file == "" {
+		return nil
+	}
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, append(buf, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, hc client.HTTPClient) error {
+	fs := flag.NewFlagSet("okayctl", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", os.Getenv("OKAYCTL_ADDR"), "URL of the replica; the default is the session's, or http://localhost:8080")
+	namespace := fs.String("namespace", "", "namespace of the keys")
+	file := fs.String("session", defaultSession(), "file the causal context is kept in; empty disables it")
+	output := fs.String("o", "table", "output format, json or table")
+	token := fs.String("token", os.Getenv("OKAYCTL_TOKEN"), "bearer token to authenticate with")
+	ca := fs.String("ca", "", "CA that signs the certificates of https replicas")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	if *output != "json" && *output != "table" {
+		return fmt.Errorf("output %q is not json or table", *output)
+	}
+
+	sess, err := loadSession(*file)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *addr != "":
+		sess.Address = *addr
+	case sess.Address == "":
+		sess.Address = "http://localhost:8080"
+	}
+	if *ca != "" {
+		cas, err := server.LoadCertPool(*ca)
+		if err != nil {
+			return err
+		}
+		hc = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: cas}}}
+	}
+	var signer auth.Signer
+	if *token != "" {
+		signer = auth.BearerToken(*token)
+	}
+
+	c := client.NewClient(hc, "okayctl", sess.Address)
+	c.SetNamespace(*namespace)
+	c.SetSigner(signer)
+	c.SetContext(sess.Contexts[sess.Address])
+	cmd := command{
+		client:  c,
+		args:    fs.Args()[1:],
+		stdin:   stdin,
+		out:     stdout,
+		json:    *output == "json",
+		http:    hc,
+		signer:  signer,
+		address: sess.Address,
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "namespace" {
+			cmd.namespace = namespace
+		}
+	})
+	var run func(context.Context) error
+	switch name := fs.Arg(0); name {
+	case "get":
+		run = cmd.get
+	case "put":
+		run = cmd.put
+	case "delete":
+		run = cmd.delete
+	case "scan":
+		run = cmd.scan
+	case "watch":
+		run = cmd.watch
+	case "view-change":
+		run = cmd.viewChange
+	case "status":
+		run = cmd.status
+	case "dump":
+		run = cmd.dump
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+	err = run(ctx)
+	// The context is kept even if the command failed, since it may have
+	// witnessed writes before failing.
+	if cc := c.Context(); cc != nil {
+		if sess.Contexts == nil {
+			sess.Contexts = make(map[string]any)
+		}
+		sess.Contexts[sess.Address] = cc
+	}
+	if saveErr := sess.save(*file); saveErr != nil && err == nil {
+		err = fmt.Errorf("save session: %w", saveErr)
+	}
+	return err
+}
+
+// command is a command with its arguments and the client to run it with.
+type command struct {
+	client *client.Client
+	args   []string
+	stdin  io.Reader
+	out    io.Writer
+	json   bool
+
+	// The change log is not read through the client, so watch needs its
+	// transport.
+	http    client.HTTPClient
+	signer  auth.Signer
+	address string
+	// namespace is the namespace to watch, or nil for every one.
+	namespace *string
+}
+
+// nargs checks that there are at least lo and, unless hi is negative, at most
+// hi arguments.
+func (c command) nargs(lo, hi int, names string) error {
+	if len(c.args) < lo || (hi >= 0 && len(c.args) > hi) {
+		return fmt.Errorf("wanted arguments %s, got %q", names, c.args)
+	}
+	return nil
+}
+
+func (c command) printJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c command) table() *tabwriter.Writer {
+	return tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+}
+
+func (c command) get(context.Context) error {
+	if err := c.nargs(1, 1, "KEY"); err != nil {
+		return err
+	}
+	value, err := c.client.Read(c.args[0])
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(client.KeyValue{Key: c.args[0], Value: value})
+	}
+	_, err = fmt.Fprintln(c.out, value)
+	return err
+}
+
+func (c command) put(context.Context) error {
+	if err := c.nargs(2, 2, "KEY VALUE"); err != nil {
+		return err
+	}
+	value := c.args[1]
+	if value == "-" {
+		buf, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return err
+		}
+		value = string(buf)
+	}
+	return c.client.Write(c.args[0], value)
+}
+
+func (c command) delete(context.Context) error {
+	if err := c.nargs(1, 1, "KEY"); err != nil {
+		return err
+	}
+	return c.client.Delete(c.args[0])
+}
+
+func (c command) scan(context.Context) error {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "most keys to list; zero is no limit")
+	if err := fs.Parse(c.args); err != nil {
+		return err
+	}
+	c.args = fs.Args()
+	if err := c.nargs(0, 1, "[PREFIX]"); err != nil {
+		return err
+	}
+	results, err := c.client.Scan(strings.Join(c.args, ""), *limit)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		if results == nil {
+			results = []client.KeyValue{}
+		}
+		return c.printJSON(results)
+	}
+	w := c.table()
+	fmt.Fprintln(w, "KEY\tVALUE")
+	for _, kv := range results {
+		fmt.Fprintf(w, "%s\t%s\n", kv.Key, kv.Value)
+	}
+	return w.Flush()
+}
+
+func (c command) watch(ctx context.Context) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	from := fs.Int("from", -1, "offset of the first change; the default is the next one")
+	freq := fs.Duration("freq", time.Second, "time between polls")
+	if err := fs.Parse(c.args); err != nil {
+		return err
+	}
+	c.args = fs.Args()
+	if err := c.nargs(0, 0, "none"); err != nil {
+		return err
+	}
+	hc := c.http
+	if c.signer != nil {
+		hc = signing{hc, c.signer}
+	}
+	newConsumer := func(checkpoint cdc.Checkpoint, out io.Writer) *cdc.Consumer {
+		consumer := cdc.NewConsumer(hc, "okayctl", c.address, checkpoint, out)
+		if c.namespace != nil {
+			consumer.SetNamespace(*c.namespace)
+		}
+		return consumer
+	}
+	checkpoint := &cdc.MemoryCheckpoint{Offset: max(*from, 0)}
+	if *from < 0 {
+		// Skip to the end of the log.
+		skip := newConsumer(checkpoint, io.Discard)
+		for ctx.Err() == nil {
+			n, err := skip.Poll(ctx)
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+			if n == 0 {
+				break
+			}
+		}
+	}
+
+	var out io.Writer = c.out
+	if !c.json {
+		w := c.table()
+		fmt.Fprintln(w, "OFFSET\tKIND\tNAMESPACE\tKEY\tVALUE\tORIGIN")
+		w.Flush()
+		out = &changeTable{w: w}
+	}
+	err := newConsumer(checkpoint, out).Run(ctx, *freq)
+	if ctx.Err() != nil {
+		// Interrupted.
+		return nil
+	}
+	return err
+}
+
+// changeTable writes the newline-delimited JSON changes written to it as rows
+// of a table.
+type changeTable struct {
+	w       *tabwriter.Writer
+	partial []byte
+}
+
+func (t *changeTable) Write(p []byte) (int, error) {
+	t.partial = append(t.partial, p...)
+	for {
+		line, rest, ok := bytes.Cut(t.partial, []byte("\n"))
+		if !ok {
+			return len(p), nil
+		}
+		t.partial = rest
+		var change server.Change
+		if err := json.Unmarshal(line, &change); err != nil {
+			return 0, err
+		}
+		value := change.Value
+		if change.Deleted {
+			value = "(deleted)"
+		}
+		fmt.Fprintf(t.w, "%d\t%s\t%s\t%s\t%s\t%s\n", change.Offset, change.Kind, change.Namespace, change.Key, value, change.Origin)
+		if err := t.w.Flush(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// signing signs the requests it sends.
+type signing struct {
+	client.HTTPClient
+	signer auth.Signer
+}
+
+func (s signing) Do(r *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if err := s.signer.Sign(r, body); err != nil {
+		return nil, err
+	}
+	return s.HTTPClient.Do(r)
+}
+
+func (c command) viewChange(context.Context) error {
+	if err := c.nargs(1, -1, "URL..."); err != nil {
+		return err
+	}
+	return c.client.ViewChange(c.args)
+}
+
+func (c command) status(context.Context) error {
+	if err := c.nargs(0, 0, "none"); err != nil {
+		return err
+	}
+	status, err := c.client.Status()
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(status)
+	}
+	w := c.table()
+	fmt.Fprintf(w, "NAME\t%s\n", status.Name)
+	fmt.Fprintf(w, "VIEW\t%s\n", strings.Join(status.View, " "))
+	fmt.Fprintf(w, "PEERS\t%s\n", strings.Join(status.Peers, " "))
+	fmt.Fprintf(w, "CLOCK\t%v\n", status.MaxCC)
+	fmt.Fprintf(w, "EVENTS\t%d\n", status.Events)
+	fmt.Fprintf(w, "KEYS\t%d\n", status.Keys)
+	fmt.Fprintf(w, "UPTIME\t%s\n", status.Uptime)
+	return w.Flush()
+}
+
+func (c command) dump(context.Context) error {
+	if err := c.nargs(0, 1, "[KEY]"); err != nil {
+		return err
+	}
+	dump, err := c.client.Dump(strings.Join(c.args, ""))
+	if err != nil {
+		return err
+	}
+	if c.json {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, dump, "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := buf.WriteTo(c.out)
+		return err
+	}
+	var state server.DebugState
+	if err := json.Unmarshal(dump, &state); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "%s at %v\n\n", state.Name, state.MaxCC)
+	w := c.table()
+	fmt.Fprintln(w, "INDEX\tNAMESPACE\tKEY\tVALUE\tCONTEXT\tORIGIN\tREPLICATED")
+	for _, e := range state.Events {
+		value := string(e.Value)
+		if e.Deleted {
+			value = "(deleted)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%v\t%s\t%s\n", e.Index, e.Namespace, e.Key, value, e.Context, e.Origin, strings.Join(e.Replicated, " "))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestOkayctl(t *testing.T) {
+	mux := http.NewServeMux()
+	server.NewServer(mux, server.Opts{
+		Name:       "a",
+		Client:     http.DefaultClient,
+		GossipFreq: time.Second,
+		Logger:     log.New(io.Discard),
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	file := filepath.Join(t.TempDir(), "session.json")
+
+	okayctl := func(stdin string, args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		args = append([]string{"-session", file, "-addr", srv.URL}, args...)
+		if err := run(context.Background(), args, strings.NewReader(stdin), &out, http.DefaultClient); err != nil {
+			t.Fatalf("okayctl %s failed: %v", strings.Join(args, " "), err)
+		}
+		return out.String()
+	}
+
+	okayctl("", "put", "user/1", "alice")
+	okayctl("bob", "put", "user/2", "-")
+	if got := okayctl("", "get", "user/2"); got != "bob\n" {
+		t.Errorf("get user/2 = %q, wanted bob", got)
+	}
+
+	// The session keeps the context of the writes.
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("no session file: %v", err)
+	}
+	var sess session
+	if err := json.Unmarshal(buf, &sess); err != nil {
+		t.Fatalf("bad session file: %v", err)
+	}
+	if sess.Address != srv.URL || sess.Contexts[srv.URL] == nil {
+		t.Errorf("session = %+v, wanted the address and its context", sess)
+	}
+	c := client.NewClient(http.DefaultClient, "test", "")
+	c.SetContext(sess.Contexts[srv.URL])
+	if n := c.EventsWitnessed(); n != 2 {
+		t.Errorf("session witnessed %d events, wanted 2", n)
+	}
+
+	var scanned []client.KeyValue
+	if err := json.Unmarshal([]byte(okayctl("", "-o", "json", "scan", "user/")), &scanned); err != nil {
+		t.Fatalf("scan output is not JSON: %v", err)
+	}
+	if len(scanned) != 2 || scanned[0] != (client.KeyValue{Key: "user/1", Value: "alice"}) {
+		t.Errorf("scan = %v", scanned)
+	}
+	if got := okayctl("", "scan", "-limit", "1"); !strings.Contains(got, "user/1") || strings.Contains(got, "user/2") {
+		t.Errorf("scan table with limit 1 =\n%s", got)
+	}
+
+	okayctl("", "delete", "user/1")
+	var out bytes.Buffer
+	err = run(context.Background(), []string{"-session", file, "get", "user/1"}, nil, &out, http.DefaultClient)
+	if err != client.ErrNotFound {
+		t.Errorf("get of a deleted key = %v, wanted %v", err, client.ErrNotFound)
+	}
+
+	okayctl("", "view-change", "http://a")
+	var status client.Status
+	if err := json.Unmarshal([]byte(okayctl("", "-o", "json", "status")), &status); err != nil {
+		t.Fatalf("status output is not JSON: %v", err)
+	}
+	if status.Name != "a" || len(status.View) != 1 || status.Keys != 1 {
+		t.Errorf("status = %+v", status)
+	}
+	if got := okayctl("", "dump", "user/2"); !strings.Contains(got, "user/2") || strings.Contains(got, "user/1") {
+		t.Errorf("dump of user/2 =\n%s", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	out.Reset()
+	if err := run(ctx, []string{"-session", file, "watch", "-from", "0", "-freq", "10ms"}, nil, &out, http.DefaultClient); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 4 || !strings.Contains(lines[3], "(deleted)") {
+		t.Errorf("watch printed\n%s\nwanted a header and three changes", out.String())
+	}
+
+	// Watching a namespace prints only its changes.
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/namespaces", strings.NewReader(`{"name":"other"}`))
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("failed to create namespace: %v %v", resp, err)
+	}
+	okayctl("", "-namespace", "other", "put", "k", "v")
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	out.Reset()
+	if err := run(ctx, []string{"-session", file, "-namespace", "other", "watch", "-from", "0", "-freq", "10ms"}, nil, &out, http.DefaultClient); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "other") {
+		t.Errorf("watch of other printed\n%s\nwanted a header and one change", out.String())
+	}
+}
+
+func TestOkayctlSessionAddresses(t *testing.T) {
+	var urls []string
+	for _, name := range []string{"a", "b"} {
+		mux := http.NewServeMux()
+		server.NewServer(mux, server.Opts{
+			Name:       name,
+			Client:     http.DefaultClient,
+			GossipFreq: time.Second,
+			Logger:     log.New(io.Discard),
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+		urls = append(urls, srv.URL)
+	}
+	file := filepath.Join(t.TempDir(), "session.json")
+	for _, url := range urls {
+		var out bytes.Buffer
+		if err := run(context.Background(), []string{"-session", file, "-addr", url, "put", "k", url}, nil, &out, http.DefaultClient); err != nil {
+			t.Fatalf("put on %s failed: %v", url, err)
+		}
+	}
+
+	// Each address keeps the context of its own writes.
+	sess, err := loadSession(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, url := range urls {
+		c := client.NewClient(http.DefaultClient, "test", "")
+		c.SetContext(sess.Contexts[url])
+		if n := c.EventsWitnessed(); n != 1 {
+			t.Errorf("session witnessed %d events on %s, wanted 1", n, url)
+		}
+	}
+}
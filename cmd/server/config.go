@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/log"
+	"github.com/spencer-p/okayv/server"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of a node. It is read from a YAML or TOML file,
+// then the environment, then flags, each overriding the last.
+type Config struct {
+	Name     string `yaml:"name" toml:"name"`
+	Port     int    `yaml:"port" toml:"port"`
+	GRPCPort int    `yaml:"grpc-port" toml:"grpc-port"`
+	LogLevel string `yaml:"log-level" toml:"log-level"`
+	// DataDir is the directory that relative paths in the config, such as
+	// the certificates and the audit log, are resolved against. The node
+	// keeps no other state there.
+	DataDir string `yaml:"data-dir" toml:"data-dir"`
+	// Peers are the URLs of every replica in the initial view.
+	Peers []string `yaml:"peers" toml:"peers"`
+	// Seeds are the URLs of replicas asked to join the cluster on startup,
+	// and Advertise the URL they reach this node at.
+	Seeds     []string     `yaml:"seeds" toml:"seeds"`
+	Advertise string       `yaml:"advertise" toml:"advertise"`
+	Gossip    GossipConfig `yaml:"gossip" toml:"gossip"`
+	TLS       TLSConfig    `yaml:"tls" toml:"tls"`
+	Limits    LimitsConfig `yaml:"limits" toml:"limits"`
+
+	AuthConfig string `yaml:"auth-config" toml:"auth-config"`
+	// ViewKey signs the view changes this node originates, and ViewKeys are
+	// the public keys of trusted origins by name.
+	ViewKey  string            `yaml:"view-key" toml:"view-key"`
+	ViewKeys map[string]string `yaml:"view-keys" toml:"view-keys"`
+	AuditLog string            `yaml:"audit-log" toml:"audit-log"`
+}
+
+// GossipConfig tunes replication with peers.
+type GossipConfig struct {
+	Freq        time.Duration `yaml:"freq" toml:"freq"`
+	Fanout      int           `yaml:"fanout" toml:"fanout"`
+	Batch       int           `yaml:"batch" toml:"batch"`
+	Mode        string        `yaml:"mode" toml:"mode"`
+	PeerPolicy  string        `yaml:"peer-policy" toml:"peer-policy"`
+	Compression string        `yaml:"compression" toml:"compression"`
+	MaxLag      time.Duration `yaml:"max-lag" toml:"max-lag"`
+}
+
+// TLSConfig names the certificate and key the node serves, and the CA that
+// signs the certificates of its peers.
+type TLSConfig struct {
+	Cert string `yaml:"cert" toml:"cert"`
+	Key  string `yaml:"key" toml:"key"`
+	CA   string `yaml:"ca" toml:"ca"`
+}
+
+// LimitsConfig bounds values, requests and shutdown.
+type LimitsConfig struct {
+	MaxValueSize int           `yaml:"max-value-size" toml:"max-value-size"`
+	ReadTimeout  time.Duration `yaml:"read-timeout" toml:"read-timeout"`
+	WriteTimeout time.Duration `yaml:"write-timeout" toml:"write-timeout"`
+	// ClientTimeout bounds requests to peers. Zero is no limit.
+	ClientTimeout time.Duration `yaml:"client-timeout" toml:"client-timeout"`
+	// DrainTimeout is how long peers are given to take the events they are
+	// missing on SIGTERM, and ShutdownTimeout how long requests in flight are
+	// given to finish after.
+	DrainTimeout    time.Duration `yaml:"drain-timeout" toml:"drain-timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown-timeout" toml:"shutdown-timeout"`
+	// ChangeRetention is the most changes kept for /cdc.
+	ChangeRetention int `yaml:"change-retention" toml:"change-retention"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Port:     8080,
+		LogLevel: "info",
+		Gossip: GossipConfig{
+			Freq:       1 * time.Second,
+			Fanout:     1,
+			Batch:      server.DefaultGossipBatch,
+			Mode:       string(server.GossipPushPull),
+			PeerPolicy: string(server.PeerRandom),
+		},
+		Limits: LimitsConfig{
+			MaxValueSize:    server.DefaultMaxValueSize,
+			ReadTimeout:     1 * time.Minute,
+			WriteTimeout:    1 * time.Minute,
+			DrainTimeout:    30 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+			ChangeRetention: server.DefaultChangeRetention,
+		},
+	}
+}
+
+// setting is a config field that can be set by a flag and an environment
+// variable.
+type setting struct {
+	flag, env, usage string
+	set              func(c *Config, v string) error
+}
+
+var settings = []setting{
+	{"name", "HOST", "name of the node; the default is the hostname", str(func(c *Config) *string { return &c.Name })},
+	{"port", "PORT", "port to serve HTTP on", integer(func(c *Config) *int { return &c.Port })},
+	{"grpc-port", "GRPC_PORT", "port to serve gRPC on; zero disables it", integer(func(c *Config) *int { return &c.GRPCPort })},
+	{"log-level", "LOG_LEVEL", "debug, info, warn or error", str(func(c *Config) *string { return &c.LogLevel })},
+	{"data-dir", "DATA_DIR", "directory that relative paths are resolved against; no other state is kept there", str(func(c *Config) *string { return &c.DataDir })},
+	{"peers", "PEERS", "comma separated URLs of every replica in the initial view", list(func(c *Config) *[]string { return &c.Peers })},
+	{"seeds", "SEEDS", "comma separated URLs of replicas to join on startup", list(func(c *Config) *[]string { return &c.Seeds })},
+	{"advertise", "ADVERTISE", "URL peers reach this node at; the default is http://name", str(func(c *Config) *string { return &c.Advertise })},
+	{"gossip-freq", "GOSSIP_FREQ", "time between gossip rounds", duration(func(c *Config) *time.Duration { return &c.Gossip.Freq })},
+	{"gossip-fanout", "GOSSIP_FANOUT", "peers gossiped with each round", integer(func(c *Config) *int { return &c.Gossip.Fanout })},
+	{"gossip-batch", "GOSSIP_BATCH", "most columns sent in one gossip request", integer(func(c *Config) *int { return &c.Gossip.Batch })},
+	{"gossip-mode", "GOSSIP_MODE", "push-pull, push or pull", str(func(c *Config) *string { return &c.Gossip.Mode })},
+	{"peer-policy", "PEER_POLICY", "random, round-robin or most-unacked", str(func(c *Config) *string { return &c.Gossip.PeerPolicy })},
+	{"gossip-compression", "GOSSIP_COMPRESSION", "gzip or zstd compression offered to peers", str(func(c *Config) *string { return &c.Gossip.Compression })},
+	{"max-lag", "MAX_LAG", "longest time without gossip before /readyz fails; the default is 3 gossip rounds", duration(func(c *Config) *time.Duration { return &c.Gossip.MaxLag })},
+	{"tls-cert", "TLS_CERT", "certificate to serve", str(func(c *Config) *string { return &c.TLS.Cert })},
+	{"tls-key", "TLS_KEY", "key of the certificate", str(func(c *Config) *string { return &c.TLS.Key })},
+	{"tls-ca", "TLS_CA", "CA that signs the certificates of peers", str(func(c *Config) *string { return &c.TLS.CA })},
+	{"max-value-size", "MAX_VALUE_SIZE", "largest value in bytes", integer(func(c *Config) *int { return &c.Limits.MaxValueSize })},
+	{"read-timeout", "READ_TIMEOUT", "time to read a request", duration(func(c *Config) *time.Duration { return &c.Limits.ReadTimeout })},
+	{"write-timeout", "WRITE_TIMEOUT", "time to write a response", duration(func(c *Config) *time.Duration { return &c.Limits.WriteTimeout })},
+	{"client-timeout", "CLIENT_TIMEOUT", "time for requests to peers", duration(func(c *Config) *time.Duration { return &c.Limits.ClientTimeout })},
+	{"drain-timeout", "DRAIN_TIMEOUT", "time peers are given to take missing events on shutdown", duration(func(c *Config) *time.Duration { return &c.Limits.DrainTimeout })},
+	{"shutdown-timeout", "SHUTDOWN_TIMEOUT", "time requests are given to finish on shutdown", duration(func(c *Config) *time.Duration { return &c.Limits.ShutdownTimeout })},
+	{"change-retention", "CHANGE_RETENTION", "most changes kept for /cdc", integer(func(c *Config) *int { return &c.Limits.ChangeRetention })},
+	{"auth-config", "AUTH_CONFIG", "JSON authentication config", str(func(c *Config) *string { return &c.AuthConfig })},
+	{"view-key", "VIEW_KEY", "key that signs view changes", str(func(c *Config) *string { return &c.ViewKey })},
+	{"view-keys", "VIEW_KEYS", "comma separated name=file keys of trusted view change origins", viewKeys},
+	{"audit-log", "AUDIT_LOG", "file that view changes are audited to", str(func(c *Config) *string { return &c.AuditLog })},
+}
+
+func str(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func integer(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func duration(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func list(field func(*Config) *[]string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = strings.Split(v, ",")
+		return nil
+	}
+}
+
+func viewKeys(c *Config, v string) error {
+	c.ViewKeys = make(map[string]string)
+	for _, entry := range strings.Split(v, ",") {
+		node, file, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("view keys must be name=file, not %q", entry)
+		}
+		c.ViewKeys[node] = file
+	}
+	return nil
+}
+
+// loadConfig reads the config file named by -config or CONFIG, then the
+// environment, then the flags in args, and validates the result.
+func loadConfig(args []string, getenv func(string) string) (*Config, error) {
+	type flagged struct {
+		setting
+		value string
+	}
+	var flags []flagged
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	file := fs.String("config", getenv("CONFIG"), "YAML config file, or TOML if it ends in .toml")
+	for _, s := range settings {
+		s := s
+		fs.Func(s.flag, fmt.Sprintf("%s (%s)", s.usage, s.env), func(v string) error {
+			flags = append(flags, flagged{s, v})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	c := defaultConfig()
+	if *file != "" {
+		if err := decodeFile(*file, c); err != nil {
+			return nil, fmt.Errorf("%s: %w", *file, err)
+		}
+	}
+	for _, s := range settings {
+		if v := getenv(s.env); v != "" {
+			if err := s.set(c, v); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+	for _, f := range flags {
+		if err := f.set(c, f.value); err != nil {
+			return nil, fmt.Errorf("-%s: %w", f.flag, err)
+		}
+	}
+
+	if c.Name == "" {
+		name, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("no hostname: %w", err)
+		}
+		c.Name = name
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validate returns every problem with c.
+func (c *Config) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(c.Port > 0 && c.Port < 1<<16, "port %d is not a port", c.Port)
+	check(c.GRPCPort >= 0 && c.GRPCPort < 1<<16, "grpc-port %d is not a port", c.GRPCPort)
+	check(c.GRPCPort != c.Port, "grpc-port and port are both %d", c.Port)
+	_, err := log.ParseLevel(c.LogLevel)
+	check(err == nil, "log-level %q is not debug, info, warn or error", c.LogLevel)
+	if c.DataDir != "" {
+		info, err := os.Stat(c.DataDir)
+		check(err == nil && info.IsDir(), "data-dir %q is not a directory", c.DataDir)
+	}
+	for _, peer := range c.Peers {
+		u, err := url.Parse(peer)
+		check(err == nil && u.Host != "" && (u.Scheme == "http" || u.Scheme == "https" || u.Scheme == server.GRPCScheme),
+			"peer %q is not an http, https or grpc URL", peer)
+	}
+	for _, seed := range c.Seeds {
+		u, err := url.Parse(seed)
+		check(err == nil && u.Host != "" && (u.Scheme == "http" || u.Scheme == "https"), "seed %q is not an http or https URL", seed)
+	}
+	if c.Advertise != "" {
+		u, err := url.Parse(c.Advertise)
+		check(err == nil && u.Host != "", "advertise %q is not a URL", c.Advertise)
+	}
+
+	g := c.Gossip
+	check(g.Freq > 0, "gossip freq must be positive")
+	check(g.Fanout > 0, "gossip fanout must be positive")
+	check(g.Batch > 0, "gossip batch must be positive")
+	switch server.GossipMode(g.Mode) {
+	case server.GossipPushPull, server.GossipPush, server.GossipPull:
+	default:
+		check(false, "gossip mode %q is not push-pull, push or pull", g.Mode)
+	}
+	switch server.PeerPolicy(g.PeerPolicy) {
+	case server.PeerRandom, server.PeerRoundRobin, server.PeerMostUnacked:
+	default:
+		check(false, "peer policy %q is not random, round-robin or most-unacked", g.PeerPolicy)
+	}
+	switch g.Compression {
+	case "", server.CompressGzip, server.CompressZstd:
+	default:
+		check(false, "gossip compression %q is not gzip or zstd", g.Compression)
+	}
+	check(g.MaxLag >= 0, "max-lag must not be negative")
+
+	check((c.TLS.Cert == "") == (c.TLS.Key == ""), "tls cert and key must be given together")
+	check(c.TLS.CA == "" || c.TLS.Cert != "", "tls ca requires a cert")
+
+	l := c.Limits
+	check(l.MaxValueSize > 0, "max-value-size must be positive")
+	check(l.ReadTimeout >= 0 && l.WriteTimeout >= 0 && l.ClientTimeout >= 0, "timeouts must not be negative")
+	check(l.DrainTimeout >= 0 && l.ShutdownTimeout >= 0, "timeouts must not be negative")
+	check(l.ChangeRetention > 0, "change-retention must be positive")
+	for node := range c.ViewKeys {
+		check(node != "", "view keys must be named")
+	}
+	return errors.Join(errs...)
+}
+
+// decodeFile decodes a YAML or TOML file, by its extension, into c. Fields
+// that c does not have are errors.
+func decodeFile(file string, c *Config) error {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(file) == ".toml" {
+		md, err := toml.Decode(string(buf), c)
+		if err != nil {
+			return err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown field %s", undecoded[0])
+		}
+		return nil
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	dec.KnownFields(true)
+	return dec.Decode(c)
+}
+
+// path resolves a file in the config against the data directory.
+func (c *Config) path(file string) string {
+	if file == "" || c.DataDir == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(c.DataDir, file)
+}
+
+func (c *Config) level() log.Level {
+	level, _ := log.ParseLevel(c.LogLevel)
+	return level
+}
+
+func (c *Config) tunables() server.Tunables {
+	return server.Tunables{
+		GossipFreq:   c.Gossip.Freq,
+		GossipFanout: c.Gossip.Fanout,
+		GossipBatch:  c.Gossip.Batch,
+		PeerPolicy:   server.PeerPolicy(c.Gossip.PeerPolicy),
+		MaxLag:       c.Gossip.MaxLag,
+	}
+}
+
+// needsRestart reports whether next changes more than the log level and the
+// tunables, which are reloaded on SIGHUP.
+func (c *Config) needsRestart(next *Config) bool {
+	fixed := func(c Config) Config {
+		c.LogLevel = ""
+		c.Gossip.Freq, c.Gossip.Fanout, c.Gossip.Batch = 0, 0, 0
+		c.Gossip.PeerPolicy, c.Gossip.MaxLag = "", 0
+		return c
+	}
+	return !reflect.DeepEqual(fixed(*c), fixed(*next))
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "okayv.yaml")
+	yaml := `
+name: a
+data-dir: ` + dir + `
+peers: [http://a:8080, http://b:8080]
+gossip:
+  freq: 200ms
+  fanout: 2
+tls:
+  cert: a.crt
+  key: a.key
+limits:
+  max-value-size: 1024
+`
+	if err := os.WriteFile(file, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"CONFIG":        file,
+		"GOSSIP_FANOUT": "3",
+		"LOG_LEVEL":     "debug",
+	}
+	c, err := loadConfig([]string{"-log-level", "warn", "-port", "9090"}, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if c.Name != "a" || len(c.Peers) != 2 || c.Gossip.Freq != 200*time.Millisecond || c.Limits.MaxValueSize != 1024 {
+		t.Errorf("config does not have the values of its file: %+v", c)
+	}
+	if c.Gossip.Fanout != 3 {
+		t.Errorf("fanout = %d, wanted the environment's 3", c.Gossip.Fanout)
+	}
+	if c.LogLevel != "warn" || c.Port != 9090 {
+		t.Errorf("log level and port = %s, %d, wanted the flags' warn, 9090", c.LogLevel, c.Port)
+	}
+	if c.Gossip.Batch == 0 || c.Limits.DrainTimeout == 0 {
+		t.Errorf("config lost its defaults: %+v", c)
+	}
+	if got := c.path(c.TLS.Cert); got != filepath.Join(dir, "a.crt") {
+		t.Errorf("cert resolves to %s, wanted it in the data dir", got)
+	}
+
+	next := *c
+	next.Gossip.Freq = time.Second
+	next.LogLevel = "error"
+	if c.needsRestart(&next) {
+		t.Errorf("changing the gossip frequency and log level needs a restart")
+	}
+	next.Port = 8081
+	if !c.needsRestart(&next) {
+		t.Errorf("changing the port does not need a restart")
+	}
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "okayv.toml")
+	conf := `
+name = "a"
+data-dir = "` + dir + `"
+peers = ["http://a:8080", "http://b:8080"]
+
+[gossip]
+freq = "200ms"
+fanout = 2
+
+[limits]
+max-value-size = 1024
+`
+	if err := os.WriteFile(file, []byte(conf), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadConfig([]string{"-config", file}, func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if c.Name != "a" || len(c.Peers) != 2 || c.Gossip.Freq != 200*time.Millisecond || c.Gossip.Fanout != 2 || c.Limits.MaxValueSize != 1024 {
+		t.Errorf("config does not have the values of its file: %+v", c)
+	}
+	if c.Gossip.Batch == 0 || c.Limits.DrainTimeout == 0 {
+		t.Errorf("config lost its defaults: %+v", c)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"PORT": "http"}, "PORT"},
+		{map[string]string{"PORT": "70000"}, "port 70000"},
+		{map[string]string{"GOSSIP_FREQ": "0s"}, "gossip freq"},
+		{map[string]string{"GOSSIP_MODE": "shout"}, `gossip mode "shout"`},
+		{map[string]string{"LOG_LEVEL": "loud"}, `log-level "loud"`},
+		{map[string]string{"PEERS": "a:8080"}, `peer "a:8080"`},
+		{map[string]string{"SEEDS": "grpc://b:9090"}, `seed "grpc://b:9090"`},
+		{map[string]string{"TLS_CERT": "a.crt"}, "cert and key"},
+		{map[string]string{"DATA_DIR": "/does/not/exist"}, "data-dir"},
+	} {
+		tc.env["HOST"] = "a"
+		_, err := loadConfig(nil, func(k string) string { return tc.env[k] })
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("loading %v = %v, wanted an error about %s", tc.env, err, tc.want)
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "okayv.yaml")
+	if err := os.WriteFile(file, []byte("gossip:\n  frequency: 1s\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{"-config", file, "-name", "a"}, func(string) string { return "" }); err == nil {
+		t.Errorf("loaded a config with an unknown field")
+	}
+	file = filepath.Join(t.TempDir(), "okayv.toml")
+	if err := os.WriteFile(file, []byte("[gossip]\nfrequency = \"1s\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{"-config", file, "-name", "a"}, func(string) string { return "" }); err == nil || !strings.Contains(err.Error(), "frequency") {
+		t.Errorf("loading a TOML config with an unknown field = %v", err)
+	}
+}
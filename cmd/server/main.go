@@ -1,50 +1,248 @@
+// Command server runs an okayv replica.
+//
+// The replica keeps its keys only in memory. On SIGTERM or SIGINT it refuses
+// writes and pushes what its peers are missing before it exits, but whatever
+// was not replicated by then is lost, as is everything on a replica whose
+// peers are all gone. The audit log is the only file it writes.
 package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/spencer-p/okayv/auth"
 	"github.com/spencer-p/okayv/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+// certCheckFreq is how often the certificate files are checked for changes.
+const certCheckFreq = time.Minute
+
 func main() {
-	name := os.Getenv("HOST")
-	if name == "" {
+	os.Exit(run())
+}
+
+// run serves until SIGTERM or SIGINT and then shuts down, returning the exit
+// status. It is nonzero if the server failed or could not drain, in which
+// case unreplicated writes were lost.
+func run() int {
+	conf, err := loadConfig(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		return 2
+	}
+	l := log.WithPrefix(fmt.Sprintf("[%s]", conf.Name))
+	l.SetLevel(conf.level())
+
+	mux := http.NewServeMux()
+	opts := server.Opts{
+		Logger:       l,
+		Client:       &http.Client{Timeout: conf.Limits.ClientTimeout},
+		Name:         conf.Name,
+		GossipFreq:   conf.Gossip.Freq,
+		MaxValueSize: conf.Limits.MaxValueSize,
+		GossipBatch:  conf.Gossip.Batch,
+		GossipFanout: conf.Gossip.Fanout,
+		GossipMode:   server.GossipMode(conf.Gossip.Mode),
+		PeerPolicy:   server.PeerPolicy(conf.Gossip.PeerPolicy),
+		Peers:        conf.Peers,
+		Seeds:        conf.Seeds,
+		Advertise:    conf.Advertise,
+		MaxLag:       conf.Gossip.MaxLag,
+
+		GossipCompression: conf.Gossip.Compression,
+		ChangeRetention:   conf.Limits.ChangeRetention,
+	}
+
+	// With a certificate, clients are served over TLS and peers must
+	// present certificates signed by the CA.
+	var serverTLS *tls.Config
+	var certs *server.CertReloader
+	if conf.TLS.Cert != "" {
 		var err error
-		name, err = os.Hostname()
+		certs, err = server.NewCertReloader(conf.path(conf.TLS.Cert), conf.path(conf.TLS.Key))
+		if err != nil {
+			l.Error("Cannot load certificate", "err", err)
+			return 1
+		}
+		cas, err := server.LoadCertPool(conf.path(conf.TLS.CA))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "no hostname: %v", err)
-			return
+			l.Error("Cannot load CA", "err", err)
+			return 1
 		}
+		serverTLS = server.ServerTLSConfig(certs, cas)
+		clientTLS := server.ClientTLSConfig(certs, cas)
+		opts.Client = &http.Client{
+			Transport: &http.Transport{TLSClientConfig: clientTLS},
+			Timeout:   conf.Limits.ClientTimeout,
+		}
+		opts.GRPCDialOptions = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(clientTLS))}
+		opts.PeerAuth = true
 	}
-	l := log.WithPrefix(fmt.Sprintf("[%s]", name))
 
-	mux := http.NewServeMux()
-	cli := http.DefaultClient
-	s := server.NewServer(mux,
-		server.Opts{
-			Logger:     l,
-			Client:     cli,
-			Name:       name,
-			GossipFreq: 1 * time.Second,
-		})
-	go s.RunBackground(context.TODO())
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// With an auth config, requests must be authenticated and are checked
+	// against its ACL.
+	if conf.AuthConfig != "" {
+		authConf, err := auth.LoadConfig(conf.path(conf.AuthConfig))
+		if err != nil {
+			l.Error("Cannot load auth config", "err", err)
+			return 1
+		}
+		opts.Authenticator = authConf.Authenticator()
+		opts.ACL = authConf.ACL
+		opts.PeerSigner = authConf.PeerSigner()
+	}
+
+	// View changes this node originates are signed with the view key, and
+	// forwarded ones must be signed by one of the view keys.
+	if conf.ViewKey != "" {
+		key, err := server.LoadViewKey(conf.path(conf.ViewKey))
+		if err != nil {
+			l.Error("Cannot load view key", "err", err)
+			return 1
+		}
+		opts.ViewKey = key
+	}
+	if len(conf.ViewKeys) > 0 {
+		opts.ViewKeys = make(map[string]ed25519.PublicKey)
+		for node, file := range conf.ViewKeys {
+			key, err := server.LoadViewPublicKey(conf.path(file))
+			if err != nil {
+				l.Error("Cannot load view key", "node", node, "err", err)
+				return 1
+			}
+			opts.ViewKeys[node] = key
+		}
 	}
+	var auditLog *os.File
+	if conf.AuditLog != "" {
+		f, err := os.OpenFile(conf.path(conf.AuditLog), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			l.Error("Cannot open audit log", "err", err)
+			return 1
+		}
+		defer f.Close()
+		auditLog = f
+		opts.AuditLog = f
+	}
+	s := server.NewServer(mux, opts)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	go s.RunBackground(ctx)
+	if certs != nil {
+		go certs.Watch(ctx, certCheckFreq)
+	}
+
 	httpServer := http.Server{
-		Addr:         ":" + port,
+		Addr:         fmt.Sprintf(":%d", conf.Port),
 		Handler:      mux,
-		ReadTimeout:  1 * time.Minute,
-		WriteTimeout: 1 * time.Minute,
+		ReadTimeout:  conf.Limits.ReadTimeout,
+		WriteTimeout: conf.Limits.WriteTimeout,
+		TLSConfig:    serverTLS,
+	}
+
+	errc := make(chan error, 2)
+	var g *grpc.Server
+	if conf.GRPCPort != 0 {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", conf.GRPCPort))
+		if err != nil {
+			l.Error("Cannot listen for gRPC", "err", err)
+			return 1
+		}
+		gopts := s.GRPCServerOptions()
+		if serverTLS != nil {
+			gopts = append(gopts, grpc.Creds(credentials.NewTLS(serverTLS)))
+		}
+		g = grpc.NewServer(gopts...)
+		s.RegisterGRPC(g)
+		l.Info("Serving gRPC", "addr", lis.Addr())
+		go func() {
+			errc <- fmt.Errorf("gRPC server exited: %w", g.Serve(lis))
+		}()
 	}
 
-	l.Info("Serving", "addr", httpServer.Addr)
-	l.Error("Exiting", "err", httpServer.ListenAndServe())
+	l.Info("Serving", "addr", httpServer.Addr, "tls", serverTLS != nil)
+	go func() {
+		if serverTLS != nil {
+			errc <- httpServer.ListenAndServeTLS("", "")
+		} else {
+			errc <- httpServer.ListenAndServe()
+		}
+	}()
+	// SIGHUP reloads the log level, tunables and certificate. Other changes
+	// are compared against the config the server started with.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for ctx.Err() == nil {
+		select {
+		case err := <-errc:
+			l.Error("Exiting", "err", err)
+			return 1
+		case <-hup:
+			next, err := loadConfig(os.Args[1:], os.Getenv)
+			if err != nil {
+				l.Error("Cannot reload config", "err", err)
+				continue
+			}
+			if conf.needsRestart(next) {
+				l.Warn("Config has changes that need a restart")
+			}
+			l.SetLevel(next.level())
+			s.Tune(next.tunables())
+			if certs != nil {
+				if err := certs.Reload(); err != nil {
+					l.Error("Cannot reload certificate", "err", err)
+				}
+			}
+			l.Info("Reloaded config")
+		case <-ctx.Done():
+		}
+	}
+
+	// Refuse writes and push what peers are missing while still serving
+	// their gossip, then stop serving.
+	l.Info("Shutting down", "drain", conf.Limits.DrainTimeout)
+	status := 0
+	drainCtx, cancel := context.WithTimeout(context.Background(), conf.Limits.DrainTimeout)
+	defer cancel()
+	if err := s.Drain(drainCtx); err != nil {
+		l.Error("Drain incomplete, unreplicated writes are lost", "err", err)
+		status = 1
+	}
+	if g != nil {
+		g.GracefulStop()
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.Limits.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		l.Error("HTTP server did not shut down", "err", err)
+		status = 1
+	}
+	if auditLog != nil {
+		if err := auditLog.Sync(); err != nil {
+			l.Error("Cannot flush audit log", "err", err)
+			status = 1
+		}
+	}
+	durable := "nothing"
+	if auditLog != nil {
+		durable = "the audit log"
+	}
+	l.Info("Stopped. Keys were kept only in memory and survive only on peers", "on-disk", durable)
+	return status
 }
@@ -0,0 +1,231 @@
+package harness
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/spencer-p/okayv/auth"
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestAuthorization(t *testing.T) {
+	for _, useGRPC := range []bool{false, true} {
+		t.Run(fmt.Sprintf("grpc=%v", useGRPC), func(t *testing.T) {
+			testAuthorization(t, useGRPC)
+		})
+	}
+}
+
+func testAuthorization(t *testing.T, useGRPC bool) {
+	nodeKey := auth.HMACKey{ID: "node", Secret: []byte("node secret")}
+	adminKey := auth.HMACKey{ID: "admin", Secret: []byte("admin secret")}
+	impl, _ := newTestImplWith(t, testImplConfig{
+		grpc:   useGRPC,
+		signer: adminKey,
+		opts: server.Opts{
+			Authenticator: auth.Any{
+				auth.Tokens{"alice-token": "alice", "bob-token": "bob"},
+				&auth.HMAC{Keys: map[string][]byte{
+					nodeKey.ID:  nodeKey.Secret,
+					adminKey.ID: adminKey.Secret,
+				}},
+			},
+			ACL: &auth.ACL{
+				Admins: []string{nodeKey.ID, adminKey.ID},
+				Rules: []auth.Rule{
+					{Principal: "alice", Namespace: auth.Wildcard, Prefix: "alice/", Ops: []auth.Op{auth.OpRead, auth.OpWrite}},
+					{Principal: auth.Wildcard, Namespace: auth.Wildcard, Prefix: "public/", Ops: []auth.Op{auth.OpRead}},
+				},
+			},
+			PeerSigner: nodeKey,
+		},
+	}, "a", "b")
+
+	clientFor := func(name string, signer auth.Signer) *client.Client {
+		c := client.NewClient(impl.srvclientpool.AlwaysReachable(), name, "http://a")
+		if signer != nil {
+			c.SetSigner(signer)
+		}
+		return c
+	}
+	anonymous := clientFor("anonymous", nil)
+	alice := clientFor("alice", auth.BearerToken("alice-token"))
+	bob := clientFor("bob", auth.BearerToken("bob-token"))
+	admin := clientFor("admin", adminKey)
+
+	if err := anonymous.Write("public/x", "1"); !errors.Is(err, client.ErrUnauthenticated) {
+		t.Errorf("anonymous write = %v, wanted %v", err, client.ErrUnauthenticated)
+	}
+	if err := clientFor("mallory", auth.BearerToken("guess")).Write("alice/x", "1"); !errors.Is(err, client.ErrUnauthenticated) {
+		t.Errorf("write with a bad token = %v, wanted %v", err, client.ErrUnauthenticated)
+	}
+	if err := alice.Write("alice/x", "1"); err != nil {
+		t.Errorf("alice write to her prefix failed: %v", err)
+	}
+	if err := alice.WriteBytes("alice/y", []byte("2"), "text/plain"); err != nil {
+		t.Errorf("alice REST write to her prefix failed: %v", err)
+	}
+	if err := alice.Write("public/x", "1"); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("alice write to public = %v, wanted %v", err, client.ErrForbidden)
+	}
+	if err := admin.Write("public/x", "1"); err != nil {
+		t.Errorf("admin write failed: %v", err)
+	}
+	if _, err := bob.Read("alice/x"); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("bob read of alice/x = %v, wanted %v", err, client.ErrForbidden)
+	}
+	if _, err := bob.ReadMany("public/x", "alice/x"); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("bob read many of alice/x = %v, wanted %v", err, client.ErrForbidden)
+	}
+
+	// Signed gossip replicates, and readers are checked on every node.
+	for _, s := range impl.servers {
+		s.Gossip()
+	}
+	alice.SetAddress("http://b")
+	if got, err := alice.Read("alice/x"); err != nil || got != "1" {
+		t.Errorf("alice read of alice/x on b = %q, %v, wanted 1", got, err)
+	}
+	bob.SetAddress("http://b")
+	if got, err := bob.Read("public/x"); err != nil || got != "1" {
+		t.Errorf("bob read of public/x on b = %q, %v, wanted 1", got, err)
+	}
+
+	// Only admins change the view.
+	view := []byte(`{"replicas":["http://a","http://b"]}`)
+	for _, tc := range []struct {
+		name   string
+		signer auth.Signer
+		want   int
+	}{
+		{"alice", auth.BearerToken("alice-token"), http.StatusForbidden},
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"admin", adminKey, http.StatusOK},
+	} {
+		req, err := http.NewRequest(http.MethodPut, "http://a/view-change", bytes.NewReader(view))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.signer != nil {
+			if err := tc.signer.Sign(req, view); err != nil {
+				t.Fatal(err)
+			}
+		}
+		resp, err := impl.srvclientpool.AlwaysReachable().Do(req)
+		if err != nil {
+			t.Fatalf("view change failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s view change returned %d, wanted %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+}
+
+func TestSignedViewChanges(t *testing.T) {
+	for _, useGRPC := range []bool{false, true} {
+		t.Run(fmt.Sprintf("grpc=%v", useGRPC), func(t *testing.T) {
+			testSignedViewChanges(t, useGRPC)
+		})
+	}
+}
+
+func testSignedViewChanges(t *testing.T, useGRPC bool) {
+	nodeKey := auth.HMACKey{ID: "node", Secret: []byte("node secret")}
+	adminKey := auth.HMACKey{ID: "admin", Secret: []byte("admin secret")}
+	nodes := []string{"a", "b", "c"}
+	privs := make(map[string]ed25519.PrivateKey)
+	pubs := make(map[string]ed25519.PublicKey)
+	for _, node := range nodes {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[node], pubs[node] = priv, pub
+	}
+	rec := &Recorder{}
+	impl, _ := newTestImplWith(t, testImplConfig{
+		grpc:     useGRPC,
+		viewKeys: privs,
+		signer:   adminKey,
+		recorder: rec,
+		opts: server.Opts{
+			Authenticator: &auth.HMAC{Keys: map[string][]byte{
+				nodeKey.ID:  nodeKey.Secret,
+				adminKey.ID: adminKey.Secret,
+			}},
+			ACL:        &auth.ACL{Admins: []string{nodeKey.ID, adminKey.ID}},
+			PeerSigner: nodeKey,
+			ViewKeys:   pubs,
+		},
+	}, nodes...)
+
+	// Every replica audits the changes it received, and the forwarded
+	// copies carry the admin that sent the change to its origin.
+	for _, s := range impl.servers {
+		entries := s.AuditEntries()
+		if len(entries) == 0 {
+			t.Errorf("%s has no audit entries", s.Name)
+		}
+		for _, e := range entries {
+			if e.Error != "" || !e.Signed || e.Principal != adminKey.ID {
+				t.Errorf("%s audited %+v, wanted a signed change by %s", s.Name, e, adminKey.ID)
+			}
+			if e.Origin != s.Name && e.Sender != nodeKey.ID {
+				t.Errorf("%s audited a change forwarded by %q, wanted %q", s.Name, e.Sender, nodeKey.ID)
+			}
+		}
+	}
+	if useGRPC {
+		return
+	}
+
+	// A forwarded change cannot be replayed or altered.
+	var forwarded, target string
+	for _, msg := range rec.record {
+		if msg.path == "/view-change" && strings.Contains(msg.request, `"donotforward":true`) {
+			forwarded, target = msg.request, msg.dst
+		}
+	}
+	if forwarded == "" {
+		t.Fatalf("no forwarded view change was recorded")
+	}
+	var change server.ViewChange
+	if err := json.Unmarshal([]byte(forwarded), &change); err != nil {
+		t.Fatal(err)
+	}
+	altered := change
+	altered.Replicas = []string{"http://evil"}
+	alteredBody, _ := json.Marshal(altered)
+	for _, tc := range []struct {
+		name string
+		body string
+		want int
+	}{
+		{"replayed", forwarded, http.StatusConflict},
+		{"altered", string(alteredBody), http.StatusForbidden},
+	} {
+		req, err := http.NewRequest(http.MethodPut, "http://"+target+"/view-change", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := nodeKey.Sign(req, []byte(tc.body)); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := impl.srvclientpool.AlwaysReachable().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s view change returned %d, wanted %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+}
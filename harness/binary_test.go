@@ -0,0 +1,43 @@
+package harness
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestBinaryValues(t *testing.T) {
+	impl, _ := newTestImpl(t, "a")
+	c := impl.realClient("alice")
+	c.SetAddress("http://a")
+
+	value := []byte{0xff, 0x00, 0xfe, 'x'}
+	if err := c.WriteBytes("x", value, "image/png"); err != nil {
+		t.Fatalf("WriteBytes failed: %v", err)
+	}
+
+	got, contentType, err := c.ReadBytes("x")
+	if err != nil {
+		t.Fatalf("ReadBytes failed: %v", err)
+	}
+	if !bytes.Equal(got, value) || contentType != "image/png" {
+		t.Errorf("ReadBytes = %q (%s), wanted %q (image/png)", got, contentType, value)
+	}
+
+	// The JSON protocol must return the same bytes.
+	str, err := c.Read("x")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if str != string(value) {
+		t.Errorf("Read = %q, wanted %q", str, value)
+	}
+
+	err = c.WriteBytes("y", make([]byte, server.DefaultMaxValueSize+1), "")
+	if !errors.Is(err, client.ErrTooLarge) {
+		t.Errorf("oversized WriteBytes returned %v, wanted %v", err, client.ErrTooLarge)
+	}
+}
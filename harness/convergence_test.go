@@ -0,0 +1,71 @@
+package harness
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/charmbracelet/log"
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+// BenchmarkConvergence reports how many gossip rounds it takes for a write on
+// every node to reach every other node.
+func BenchmarkConvergence(b *testing.B) {
+	for _, policy := range []server.PeerPolicy{server.PeerRandom, server.PeerRoundRobin, server.PeerMostUnacked} {
+		for _, mode := range []server.GossipMode{server.GossipPushPull, server.GossipPush, server.GossipPull} {
+			for _, size := range []int{3, 5, 9} {
+				opts := server.Opts{
+					Logger:     log.New(io.Discard),
+					PeerPolicy: policy,
+					GossipMode: mode,
+				}
+				b.Run(fmt.Sprintf("%s/%s/nodes=%d", policy, mode, size), func(b *testing.B) {
+					rounds := 0
+					for n := 0; n < b.N; n++ {
+						rounds += converge(b, size, opts)
+					}
+					b.ReportMetric(float64(rounds)/float64(b.N), "rounds/op")
+				})
+			}
+		}
+	}
+}
+
+// converge writes one key on each of size nodes and gossips until every node
+// has every key. It returns the number of rounds of gossip.
+func converge(b *testing.B, size int, opts server.Opts) int {
+	var nodes []string
+	for n := 0; n < size; n++ {
+		nodes = append(nodes, fmt.Sprintf("node_%02d", n))
+	}
+	keys := nodes
+	impl, _ := newTestImplWith(b, testImplConfig{opts: opts}, nodes...)
+	for _, node := range nodes {
+		if err := impl.Write("writer-"+node, node, node, "1"); err != nil {
+			b.Fatalf("write failed: %v", err)
+		}
+	}
+
+	converged := func() bool {
+		for _, node := range nodes {
+			c := client.NewClient(impl.srvclientpool.AlwaysReachable(), "checker", "http://"+node)
+			values, err := c.ReadMany(keys...)
+			if err != nil || len(values) != len(keys) {
+				return false
+			}
+		}
+		return true
+	}
+	for rounds := 1; rounds <= 100*size; rounds++ {
+		for _, s := range impl.servers {
+			s.Gossip()
+		}
+		if converged() {
+			return rounds
+		}
+	}
+	b.Fatalf("%d nodes did not converge", size)
+	return 0
+}
@@ -0,0 +1,107 @@
+package harness
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestCRDTs(t *testing.T) {
+	impl, model := newTestImpl(t, "a", "b")
+	alice := impl.realClient("alice")
+	alice.SetAddress("http://a")
+	bob := impl.realClient("bob")
+	bob.SetAddress("http://b")
+
+	// Seed the set on both replicas so that bob's remove observes the add.
+	if err := alice.SetAdd("s", "x"); err != nil {
+		t.Fatalf("SetAdd failed: %v", err)
+	}
+	for _, s := range impl.servers {
+		s.Gossip()
+	}
+
+	model.Partition("a", "b")
+	for _, op := range []func() error{
+		func() error { return alice.Increment("n", 2) },
+		func() error { return bob.Increment("n", 5) },
+		func() error { return bob.Increment("n", -1) },
+		func() error { return alice.SetAdd("s", "x", "y") },
+		func() error { return bob.SetRemove("s", "x") },
+		func() error { return bob.SetAdd("s", "z") },
+		func() error { return alice.SetRegister("r", []byte("first"), "text/plain") },
+		func() error { return bob.SetRegister("r", []byte("second"), "text/plain") },
+	} {
+		if err := op(); err != nil {
+			t.Fatalf("operation failed: %v", err)
+		}
+	}
+	model.Connect("a", "b")
+	for i := 0; i < 3; i++ {
+		for _, s := range impl.servers {
+			s.Gossip()
+		}
+	}
+
+	for _, node := range []string{"a", "b"} {
+		c := client.NewClient(impl.srvclientpool.AlwaysReachable(), "carol", "http://"+node)
+		if n, err := c.Counter("n"); err != nil || n != 6 {
+			t.Errorf("counter on %s = %d, %v, wanted 6", node, n, err)
+		}
+		// Alice's concurrent add of x survives bob's remove.
+		if members, err := c.Members("s"); err != nil || !slices.Equal(members, []string{"x", "y", "z"}) {
+			t.Errorf("set on %s = %v, %v, wanted [x y z]", node, members, err)
+		}
+		if value, err := c.Read("r"); err != nil || value != "second" {
+			t.Errorf("register on %s = %q, %v, wanted second", node, value, err)
+		}
+	}
+
+	if err := alice.Write("n", "1"); err == nil {
+		t.Errorf("plain write over a counter succeeded")
+	}
+	if err := alice.SetAdd("n", "x"); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("SetAdd on a counter returned %v, wanted %v", err, client.ErrConflict)
+	}
+}
+
+func TestGCounter(t *testing.T) {
+	impl, model := newTestImpl(t, "a", "b")
+	if code := impl.request(t, http.MethodPut, "a", "/namespaces", server.Namespace{Name: "durable", ReplicationFactor: 2}, nil); code != http.StatusOK {
+		t.Fatalf("create namespace returned %d", code)
+	}
+	alice := impl.realClient("alice")
+	alice.SetAddress("http://a")
+	alice.SetNamespace("durable")
+
+	// The op is replicated to b before it returns.
+	if err := alice.IncrementCounter("g", client.GCounter, 2); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+	bob := impl.realClient("bob")
+	bob.SetAddress("http://b")
+	bob.SetNamespace("durable")
+	if n, err := bob.Counter("g"); err != nil || n != 2 {
+		t.Fatalf("counter on b = %d, %v, wanted 2", n, err)
+	}
+
+	// Increments need not restate the type, but cannot decrement it or
+	// change it. Cut off from a, they are pending.
+	model.Partition("a", "b")
+	if err := bob.Increment("g", 3); !errors.Is(err, client.ErrPending) {
+		t.Errorf("partitioned Increment of a g-counter returned %v, wanted %v", err, client.ErrPending)
+	}
+	if err := bob.Increment("g", -1); err == nil {
+		t.Errorf("decrement of a g-counter succeeded")
+	}
+	if err := bob.IncrementCounter("g", client.PNCounter, 1); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("IncrementCounter of a g-counter as a pn-counter = %v, wanted %v", err, client.ErrConflict)
+	}
+	if n, err := bob.Counter("g"); err != nil || n != 5 {
+		t.Errorf("counter on b = %d, %v, wanted 5", n, err)
+	}
+}
@@ -0,0 +1,64 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestDebugState(t *testing.T) {
+	impl, _ := newTestImpl(t, "a", "b")
+	impl.mustWrite(t, "alice", "a", "", "k1", "v1")
+	impl.mustWrite(t, "alice", "a", "", "k2", "v2")
+	impl.mustWrite(t, "alice", "a", "", "k1", "v3")
+	impl.servers[0].Gossip()
+
+	var state server.DebugState
+	if code := impl.request(t, http.MethodGet, "a", "/debug/state", nil, &state); code != http.StatusOK {
+		t.Fatalf("GET /debug/state = %d", code)
+	}
+	if state.Name != "a" || len(state.Events) != 3 || len(state.ByID) != 3 {
+		t.Fatalf("state of a has %d events and %d IDs, wanted 3", len(state.Events), len(state.ByID))
+	}
+	wantLatest := []server.DebugLatest{{Key: "k1", Index: 2}, {Key: "k2", Index: 1}}
+	if !slices.Equal(state.Latest, wantLatest) {
+		t.Errorf("latest of a = %v, wanted %v", state.Latest, wantLatest)
+	}
+	for _, ev := range state.Events {
+		if !slices.Equal(ev.Replicated, []string{"a", "b"}) {
+			t.Errorf("event %d is replicated to %v, wanted a and b", ev.Index, ev.Replicated)
+		}
+		if state.ByID[ev.ID] != ev.Index {
+			t.Errorf("byid of %s = %d, wanted %d", ev.ID, state.ByID[ev.ID], ev.Index)
+		}
+	}
+	if state.MaxCC["a"] != 3 {
+		t.Errorf("maxcc of a = %v", state.MaxCC)
+	}
+
+	// Picking the next batch for b moves its acked index past the events.
+	impl.servers[0].Gossip()
+	state = impl.servers[0].DebugState(server.DebugQuery{})
+	if state.Acked["b"] != 3 {
+		t.Errorf("acked of a = %v, wanted b at 3", state.Acked)
+	}
+
+	var key server.DebugState
+	if code := impl.request(t, http.MethodGet, "a", "/debug/state", server.DebugQuery{Key: "k1"}, &key); code != http.StatusOK {
+		t.Fatalf("GET /debug/state for k1 = %d", code)
+	}
+	if len(key.Events) != 2 || len(key.Latest) != 1 || len(key.ByID) != 2 {
+		t.Errorf("state of k1 has %d events, %d latest and %d IDs, wanted 2, 1 and 2", len(key.Events), len(key.Latest), len(key.ByID))
+	}
+
+	// Dumps of the same state are identical.
+	first, _ := json.Marshal(impl.servers[0].DebugState(server.DebugQuery{}))
+	second, _ := json.Marshal(impl.servers[0].DebugState(server.DebugQuery{}))
+	if !bytes.Equal(first, second) {
+		t.Errorf("dumps of the same state differ:\n%s\n%s", first, second)
+	}
+}
@@ -0,0 +1,49 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestDrain(t *testing.T) {
+	impl, model := newTestImpl(t, "a", "b")
+	a := impl.servers[0]
+	model.Partition("a", "b")
+	impl.mustWrite(t, "alice", "a", "", "k", "v")
+
+	// With b unreachable the drain runs out of time.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := a.Drain(ctx); err == nil {
+		t.Errorf("drain succeeded with b partitioned")
+	}
+
+	c := impl.realClient("alice")
+	c.SetAddress("http://a")
+	if err := c.Write("k2", "v"); !errors.Is(err, client.ErrUnavailable) {
+		t.Errorf("write while draining = %v, wanted %v", err, client.ErrUnavailable)
+	}
+	if code := impl.request(t, http.MethodGet, "a", "/readyz", nil, nil); code != http.StatusServiceUnavailable {
+		t.Errorf("GET /readyz while draining = %d, wanted %d", code, http.StatusServiceUnavailable)
+	}
+	if got, err := c.Read("k"); err != nil || got != "v" {
+		t.Errorf("read while draining = %q, %v, wanted v", got, err)
+	}
+
+	model.Connect("a", "b")
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.Drain(ctx); err != nil {
+		t.Fatalf("drain failed: %v", err)
+	}
+	var out server.KV
+	if code := impl.request(t, http.MethodGet, "b", "/read", server.KV{Key: "k"}, &out); code != http.StatusOK || string(out.Value) != "v" {
+		t.Errorf("read k on b after drain = %d %q, wanted v", code, out.Value)
+	}
+}
@@ -0,0 +1,101 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestGossipEncoding(t *testing.T) {
+	for _, useGRPC := range []bool{false, true} {
+		for _, compression := range []string{"", server.CompressGzip, server.CompressZstd} {
+			name := fmt.Sprintf("grpc=%v/compression=%q", useGRPC, compression)
+			t.Run(name, func(t *testing.T) {
+				testGossipEncoding(t, useGRPC, compression)
+			})
+		}
+	}
+}
+
+func testGossipEncoding(t *testing.T, useGRPC bool, compression string) {
+	recorder := &Recorder{}
+	impl, _ := newTestImplWith(t, testImplConfig{
+		grpc:     useGRPC,
+		opts:     server.Opts{GossipCompression: compression},
+		recorder: recorder,
+	}, "a", "b")
+
+	var keys []string
+	for n := 0; n < 50; n++ {
+		key := fmt.Sprintf("k%03d", n)
+		keys = append(keys, key)
+		impl.mustWrite(t, "alice", "a", "", key, `{"name":"value","n":1}`)
+	}
+	impl.mustWrite(t, "bob", "b", "", "fromb", "v")
+	a, b := impl.servers[0], impl.servers[1]
+	for i := 0; i < 3; i++ {
+		a.Gossip()
+		b.Gossip()
+	}
+
+	c := impl.realClient("alice")
+	c.SetAddress("http://b")
+	values, err := c.ReadMany(keys...)
+	if err != nil {
+		t.Fatalf("ReadMany failed: %v", err)
+	}
+	if len(values) != len(keys) {
+		t.Errorf("b has %d of %d keys", len(values), len(keys))
+	}
+	c.SetAddress("http://a")
+	if got, err := c.Read("fromb"); err != nil || got != "v" {
+		t.Errorf("Read(fromb) on a = %q, %v, wanted v", got, err)
+	}
+
+	for _, s := range []*server.Server{a, b} {
+		stats := s.GossipStats()
+		if stats.SentBytes == 0 || stats.ReceivedBytes == 0 {
+			t.Errorf("%s counted no gossip: %+v", s.Name, stats)
+		}
+		if compression == "" && (stats.SentWireBytes != stats.SentBytes || stats.ReceivedWireBytes != stats.ReceivedBytes) {
+			t.Errorf("%s counted compressed bytes without compression: %+v", s.Name, stats)
+		}
+	}
+	// The replies of b are compressed from the start.
+	if stats := b.GossipStats(); compression != "" && stats.SentWireBytes >= stats.SentBytes {
+		t.Errorf("b sent %d wire bytes for %d bytes of gossip, wanted fewer", stats.SentWireBytes, stats.SentBytes)
+	}
+
+	if useGRPC || compression != "" {
+		return
+	}
+	acks := 0
+	for _, msg := range recorder.record {
+		if msg.path != "/gossip" || msg.request == "" {
+			continue
+		}
+		var req server.Gossip
+		if err := json.Unmarshal([]byte(msg.request), &req); err != nil {
+			t.Fatalf("failed to decode gossip: %v", err)
+		}
+		if len(req.Columns) > 0 && !req.Delta {
+			t.Errorf("gossip from %s has full contexts, wanted delta encoded", req.Host)
+		}
+		for _, col := range req.Columns[min(1, len(req.Columns)):] {
+			if len(col.Clock.Context) > 1 {
+				t.Errorf("gossip from %s encoded context %v, wanted one entry", req.Host, col.Clock.Context)
+			}
+		}
+		if len(req.Acks) > 0 {
+			acks++
+			if len(req.Columns) > 0 {
+				t.Errorf("ack from %s carried %d columns", req.Host, len(req.Columns))
+			}
+		}
+	}
+	if acks == 0 {
+		t.Errorf("no gossip carried acks")
+	}
+}
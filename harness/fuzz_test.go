@@ -1,12 +1,10 @@
 package harness
 
 import (
-	"context"
 	"math/rand"
 	"os"
 	"testing"
 
-	"github.com/spencer-p/okayv/client"
 	"github.com/spencer-p/okayv/tsgen"
 )
 
@@ -25,6 +23,28 @@ func FuzzOkayV(f *testing.F) {
 		2, 0, 2, 4, // Alices reads 4 from node 2, should succeed eventually.
 		2, 0, 2, 4, // Alices reads 4 from node 2, should succeed eventually.
 	})
+	f.Add([]byte{
+		0, 1, // Register node 1.
+		0, 2, // Register node 2.
+		1, 0, 1, 2, 2, // Alice writes 2=2 to node 1.
+		1, 0, 1, 3, 3, // Alice writes 3=3 to node 1.
+		5, 1, 2, 2, 2, 3, // Bob reads 2 and 3 from node 2.
+		4, 1, 2, // Partition nodes 1 and 2.
+		1, 0, 1, 2, 4, // Alice writes 2=4 to node 1.
+		1, 0, 1, 3, 5, // Alice writes 3=5 to node 1.
+		5, 1, 1, 2, 2, 3, // Bob reads 2 and 3 from node 1.
+		5, 1, 2, 2, 2, 3, // Bob reads 2 and 3 from node 2, should fail.
+	})
+	f.Add([]byte{
+		0, 1, // Register node 1.
+		0, 2, // Register node 2.
+		4, 1, 2, // Partition nodes 1 and 2.
+		6, 0, 1, 7, 2, // Alice adds 2 to counter 7 on node 1.
+		6, 1, 2, 7, 3, // Bob adds 3 to counter 7 on node 2.
+		6, 1, 2, 7, 0xff, // Bob subtracts 1 from counter 7 on node 2.
+		3, 1, 2, // Heal partition between 1 and 2.
+		6, 0, 1, 7, 1, // Alice adds 1 to counter 7 on node 1.
+	})
 	f.Fuzz(func(t *testing.T, input []byte) {
 		program, err := tsgen.Parse(input)
 		if err != nil {
@@ -35,54 +55,69 @@ func FuzzOkayV(f *testing.F) {
 		}
 
 		for i := 0; i < 10; i++ {
-			random := rand.New(rand.NewSource(int64(i)))
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
-			model := tsgen.NewModel()
-			recorder := Recorder{}
-			impl := &MyImpl{
-				ctx:            ctx,
-				srvclientpool:  NewClientPool(model, &recorder),
-				realclientpool: make(map[string]*client.Client),
-			}
-			for _, instr := range program {
-				if err := instr.Apply(model, impl); err != nil {
-					t.Errorf("%#v error: %v", instr, err)
-					break
-				}
-				// Randomly allow all servers to gossip.
-				// TODO: Shuffle the order.
-				if random.Int()%2 == 0 {
-					for _, s := range impl.servers {
-						s.Gossip()
-					}
-				}
+			// Alternate runs replicate over gRPC, and alternate pairs of
+			// runs use the binary client routes.
+			runProgram(t, program, int64(i), i%2 == 1, i/2%2 == 1)
+			if t.Failed() {
+				return
 			}
+		}
+	})
+}
 
-			err = tsgen.ValidateCausality(impl.Record)
-			if err != nil {
-				t.Errorf("causality violated: %v", err)
-				for i, r := range impl.Record {
-					t.Logf("%d\t%#v", i, r)
-				}
+// runProgram applies a program to a fresh cluster and validates the result.
+func runProgram(t *testing.T, program tsgen.Program, seed int64, useGRPC, binary bool) {
+	random := rand.New(rand.NewSource(seed))
+	recorder := &Recorder{}
+	impl, model := newTestImplWith(t, testImplConfig{
+		grpc:     useGRPC,
+		binary:   binary,
+		recorder: recorder,
+	})
+	for _, instr := range program {
+		if err := instr.Apply(model, impl); err != nil {
+			t.Errorf("%#v error: %v", instr, err)
+			break
+		}
+		// Randomly allow all servers to gossip.
+		// TODO: Shuffle the order.
+		if random.Int()%2 == 0 {
+			for _, s := range impl.servers {
+				s.Gossip()
 			}
+		}
+	}
 
-			debug := os.Getenv("DEBUG") != ""
-			if t.Failed() || debug {
-				t.Logf("program:")
-				for i, instr := range program {
-					t.Logf("%d\t%#v", i, instr)
-				}
-				file, err := writeSequenceHTML(recorder.ToSequence())
-				if err != nil {
-					t.Errorf("failed to write sequence: %v", err)
-				} else {
-					t.Logf("wrote sequence to %s", file)
-				}
-				if t.Failed() {
-					return // end test
-				}
-			}
+	if err := tsgen.ValidateCausality(impl.Record); err != nil {
+		t.Errorf("causality violated: %v", err)
+		for i, r := range impl.Record {
+			t.Logf("%d\t%#v", i, r)
 		}
-	})
+	}
+
+	if len(impl.Increments) > 0 {
+		if err := impl.converge(model); err != nil {
+			t.Errorf("counters did not converge: %v", err)
+		}
+	}
+
+	debug := os.Getenv("DEBUG") != ""
+	if t.Failed() || debug {
+		t.Logf("program:")
+		for i, instr := range program {
+			t.Logf("%d\t%#v", i, instr)
+		}
+		file, err := writeSequenceHTML(recorder.ToSequence())
+		if err != nil {
+			t.Errorf("failed to write sequence: %v", err)
+		} else {
+			t.Logf("wrote sequence to %s", file)
+		}
+		file, err = writeStates(impl.servers)
+		if err != nil {
+			t.Errorf("failed to write server states: %v", err)
+		} else {
+			t.Logf("wrote server states to %s", file)
+		}
+	}
 }
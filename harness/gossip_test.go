@@ -0,0 +1,109 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestGossipBatches(t *testing.T) {
+	recorder := &Recorder{}
+	impl, _ := newTestImplWith(t, testImplConfig{
+		opts:     server.Opts{GossipBatch: 16},
+		recorder: recorder,
+	}, "a", "b")
+
+	var keys []string
+	for n := 0; n < 100; n++ {
+		key := fmt.Sprintf("k%03d", n)
+		keys = append(keys, key)
+		impl.mustWrite(t, "alice", "a", "", key, "v")
+	}
+	impl.servers[0].Gossip()
+
+	c := impl.realClient("alice")
+	c.SetAddress("http://b")
+	values, err := c.ReadMany(keys...)
+	if err != nil {
+		t.Fatalf("ReadMany failed: %v", err)
+	}
+	if len(values) != len(keys) {
+		t.Errorf("b has %d of %d keys after one round of gossip", len(values), len(keys))
+	}
+
+	pushes := 0
+	for _, msg := range recorder.record {
+		if msg.path != "/gossip" || msg.request == "" {
+			continue
+		}
+		var req server.Gossip
+		if err := json.Unmarshal([]byte(msg.request), &req); err != nil {
+			t.Fatalf("failed to decode gossip: %v", err)
+		}
+		if len(req.Columns) > 16 {
+			t.Errorf("gossip from %s carried %d columns, wanted at most 16", req.Host, len(req.Columns))
+		}
+		pushes++
+	}
+	if pushes < 100/16 {
+		t.Errorf("sent %d gossip requests, wanted at least %d", pushes, 100/16)
+	}
+}
+
+func TestSlowPeerDoesNotBlockReads(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	slow := DoFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/gossip" {
+			return httptest.NewRecorder().Result(), nil
+		}
+		once.Do(func() { close(entered) })
+		<-release
+		return nil, context.DeadlineExceeded
+	})
+
+	mux := http.NewServeMux()
+	s := server.NewServer(mux, server.Opts{Name: "a", Client: slow})
+	if err := viewChange("a", mux, []string{"http://a", "http://b"}, nil); err != nil {
+		t.Fatalf("view change failed: %v", err)
+	}
+	serve := func(method, path, body string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, "http://a"+path, strings.NewReader(body)))
+		return w.Code
+	}
+	if code := serve(http.MethodPut, "/v1/kv/x", "1"); code != http.StatusNoContent {
+		t.Fatalf("write failed with %d", code)
+	}
+
+	gossiped := make(chan struct{})
+	go func() {
+		s.Gossip()
+		close(gossiped)
+	}()
+	<-entered
+
+	read := make(chan int)
+	go func() {
+		read <- serve(http.MethodGet, "/v1/kv/x", "")
+	}()
+	select {
+	case code := <-read:
+		if code != http.StatusOK {
+			t.Errorf("read during gossip returned %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("read blocked while gossip was in flight")
+	}
+	close(release)
+	<-gossiped
+}
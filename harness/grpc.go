@@ -0,0 +1,93 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/spencer-p/okayv/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// UseGRPC makes the pool announce nodes to each other with the gRPC scheme,
+// so that replication runs over in-memory gRPC listeners. Client requests are
+// still served over HTTP.
+func (p *ClientPool) UseGRPC() {
+	p.scheme = server.GRPCScheme
+}
+
+// ServeGRPC serves a node's gRPC API on an in-memory listener until ctx is
+// done.
+func (p *ClientPool) ServeGRPC(ctx context.Context, node string, s *server.Server) {
+	lis := bufconn.Listen(1 << 20)
+	p.m.Lock()
+	p.listeners[node] = lis
+	p.m.Unlock()
+
+	g := grpc.NewServer(s.GRPCServerOptions()...)
+	s.RegisterGRPC(g)
+	go func() {
+		_ = g.Serve(lis)
+	}()
+	go func() {
+		<-ctx.Done()
+		g.Stop()
+	}()
+}
+
+// GRPCDialOptions returns the dial options a node uses to reach its peers.
+// Like Client, calls fail while the peer is partitioned from the node.
+func (p *ClientPool) GRPCDialOptions(origin string) []grpc.DialOption {
+	dial := func(ctx context.Context, addr string) (net.Conn, error) {
+		p.m.Lock()
+		lis, ok := p.listeners[addr]
+		p.m.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("could not find host %q", addr)
+		}
+		return lis.DialContext(ctx)
+	}
+	intercept := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		target := strings.TrimPrefix(cc.Target(), "passthrough:///")
+		if !p.topo.Reachable(origin, target) {
+			return status.Error(codes.Unavailable, context.DeadlineExceeded.Error())
+		}
+		p.recorder.recordRPC(origin, target, method, req.(proto.Message), "")
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		p.recorder.recordRPC(target, origin, method, reply.(proto.Message), status.Code(err).String())
+		return err
+	}
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(dial),
+		grpc.WithUnaryInterceptor(intercept),
+	}
+}
+
+// recordRPC records a gRPC request, or a response if code is set, as its
+// JSON equivalent.
+func (rec *Recorder) recordRPC(src, dst, method string, msg proto.Message, code string) {
+	body, _ := protojson.Marshal(msg)
+	rec.m.Lock()
+	defer rec.m.Unlock()
+	m := RecordedMessage{
+		dst:    dst,
+		src:    src,
+		path:   method,
+		method: "RPC",
+	}
+	if code == "" {
+		m.request = string(body)
+	} else {
+		m.response = string(body)
+		m.status = code
+	}
+	rec.record = append(rec.record, m)
+}
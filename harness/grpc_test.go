@@ -0,0 +1,53 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/spencer-p/okayv/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCClientOperations(t *testing.T) {
+	impl, _ := newTestImplWith(t, testImplConfig{grpc: true}, "a", "b")
+	ctx := impl.ctx
+	dial := func(node string) pb.OkayVClient {
+		conn, err := grpc.NewClient("passthrough:///"+node, impl.srvclientpool.GRPCDialOptions("tester")...)
+		if err != nil {
+			t.Fatalf("failed to dial %s: %v", node, err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return pb.NewOkayVClient(conn)
+	}
+	a, b := dial("a"), dial("b")
+
+	written, err := a.Write(ctx, &pb.KV{Key: "x", Value: []byte("1"), ContentType: "text/plain"})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, s := range impl.servers {
+		s.Gossip()
+	}
+	got, err := b.Read(ctx, &pb.KV{Key: "x", Context: written.Context})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if string(got.Value) != "1" || got.ContentType != "text/plain" || got.Version != written.Version {
+		t.Errorf("Read = %q (%s) at %s, wanted 1 (text/plain) at %s", got.Value, got.ContentType, got.Version, written.Version)
+	}
+
+	if _, err := b.Delete(ctx, &pb.KV{Key: "x", Context: got.Context, IfMatch: "stale"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Delete with stale version returned %v, wanted %v", err, codes.FailedPrecondition)
+	}
+	deleted, err := b.Delete(ctx, &pb.KV{Key: "x", Context: got.Context})
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	for _, s := range impl.servers {
+		s.Gossip()
+	}
+	if _, err := a.Read(ctx, &pb.KV{Key: "x", Context: deleted.Context}); status.Code(err) != codes.NotFound {
+		t.Errorf("Read of deleted key returned %v, wanted %v", err, codes.NotFound)
+	}
+}
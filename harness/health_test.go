@@ -0,0 +1,116 @@
+package harness
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestReady(t *testing.T) {
+	impl, model := newTestImplWith(t, testImplConfig{
+		opts: server.Opts{MaxLag: 50 * time.Millisecond},
+	}, "a", "b")
+	a := impl.servers[0]
+	if code := impl.request(t, http.MethodGet, "a", "/healthz", nil, nil); code != http.StatusOK {
+		t.Errorf("GET /healthz = %d", code)
+	}
+	if err := a.Ready(); err == nil {
+		t.Errorf("a is ready before gossiping with a peer")
+	}
+	a.Gossip()
+	if code := impl.request(t, http.MethodGet, "a", "/readyz", nil, nil); code != http.StatusOK {
+		t.Errorf("a is not ready after gossip: %d", code)
+	}
+
+	model.Partition("a", "b")
+	time.Sleep(100 * time.Millisecond)
+	a.Gossip()
+	if err := a.Ready(); err == nil {
+		t.Errorf("a is ready without gossip for %v", 100*time.Millisecond)
+	}
+	if code := impl.request(t, http.MethodGet, "a", "/readyz", nil, nil); code != http.StatusServiceUnavailable {
+		t.Errorf("GET /readyz = %d, wanted %d", code, http.StatusServiceUnavailable)
+	}
+	if code := impl.request(t, http.MethodGet, "a", "/healthz", nil, nil); code != http.StatusOK {
+		t.Errorf("GET /healthz on an isolated node = %d", code)
+	}
+
+	model.Connect("a", "b")
+	a.Gossip()
+	if err := a.Ready(); err != nil {
+		t.Errorf("a is not ready after gossip: %v", err)
+	}
+}
+
+func TestReadyDefaultMaxLag(t *testing.T) {
+	impl, model := newTestImpl(t, "a", "b")
+	a := impl.servers[0]
+	a.Gossip()
+	if err := a.Ready(); err != nil {
+		t.Fatalf("a is not ready after gossip: %v", err)
+	}
+
+	// a stops gossiping for longer than DefaultMaxLagRounds rounds.
+	model.Partition("a", "b")
+	time.Sleep(server.DefaultMaxLagRounds*10*time.Millisecond + 20*time.Millisecond)
+	a.Gossip()
+	if err := a.Ready(); err == nil {
+		t.Errorf("a is ready after missing %d rounds of gossip", server.DefaultMaxLagRounds)
+	}
+}
+
+func TestReadyWithoutView(t *testing.T) {
+	s := server.NewServer(http.NewServeMux(), server.Opts{Name: "lonely"})
+	if err := s.Ready(); err == nil {
+		t.Errorf("server is ready without a view")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	impl, _ := newTestImpl(t, "a", "b")
+	impl.mustWrite(t, "alice", "a", "", "k1", "v")
+	impl.mustWrite(t, "alice", "a", "", "k2", "v")
+	c := impl.realClient("alice")
+	c.SetAddress("http://a")
+	if err := c.Delete("k2"); err != nil {
+		t.Fatalf("Delete(k2) failed: %v", err)
+	}
+
+	var status server.Status
+	if code := impl.request(t, http.MethodGet, "a", "/status", nil, &status); code != http.StatusOK {
+		t.Fatalf("GET /status = %d", code)
+	}
+	if status.Name != "a" || !slices.Equal(status.Peers, []string{"b"}) {
+		t.Errorf("status of a names %q with peers %v", status.Name, status.Peers)
+	}
+	if len(status.View) != 2 {
+		t.Errorf("view of a = %v, wanted both nodes", status.View)
+	}
+	if status.Events != 3 || status.Keys != 1 {
+		t.Errorf("a has %d events and %d keys, wanted 3 and 1", status.Events, status.Keys)
+	}
+	if status.MaxCC["a"] != 3 {
+		t.Errorf("maxcc of a = %v", status.MaxCC)
+	}
+	if status.Uptime <= 0 {
+		t.Errorf("uptime of a = %v", status.Uptime)
+	}
+}
+
+func TestTune(t *testing.T) {
+	impl, model := newTestImpl(t, "a", "b")
+	a := impl.servers[0]
+	a.Gossip()
+	model.Partition("a", "b")
+	time.Sleep(10 * time.Millisecond)
+	if err := a.Ready(); err != nil {
+		t.Fatalf("a is not ready within the default max lag: %v", err)
+	}
+	a.Tune(server.Tunables{MaxLag: time.Millisecond})
+	if err := a.Ready(); err == nil {
+		t.Errorf("a is ready after lowering its max lag")
+	}
+}
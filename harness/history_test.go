@@ -0,0 +1,73 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/spencer-p/okayv/server"
+	"github.com/spencer-p/okayv/tsgen"
+)
+
+func TestHistoryTieBreak(t *testing.T) {
+	p := tsgen.Program{
+		tsgen.RegisterNode{Node: "a"},
+		tsgen.RegisterNode{Node: "b"},
+		tsgen.Partition{A: "a", B: "b"},
+		tsgen.Write{Client: "alice", Node: "a", Key: "x", Value: "1"},
+		tsgen.Write{Client: "bob", Node: "b", Key: "x", Value: "2"},
+		tsgen.Connect{A: "a", B: "b"},
+	}
+
+	impl, model := newTestImpl(t)
+	for _, instr := range p {
+		if err := instr.Apply(model, impl); err != nil {
+			t.Fatalf("%#v error: %v", instr, err)
+		}
+	}
+	for _, s := range impl.servers {
+		s.Gossip()
+	}
+
+	table := []struct {
+		node string
+		want []server.Version
+	}{{
+		node: "a",
+		want: []server.Version{
+			{Value: []byte("1"), Origin: "a", Superseded: server.SupersededByTieBreak},
+			{Value: []byte("2"), Origin: "b"},
+		},
+	}, {
+		node: "b",
+		want: []server.Version{
+			{Value: []byte("2"), Origin: "b"},
+			{Value: []byte("1"), Origin: "a", Superseded: server.SupersededByTieBreak, Dropped: true},
+		},
+	}}
+	for _, tc := range table {
+		t.Run(tc.node, func(t *testing.T) {
+			var history server.History
+			body := bytes.NewBufferString(`{"key": "x"}`)
+			req, _ := http.NewRequest(http.MethodGet, "http://"+tc.node+"/history", body)
+			resp, err := impl.srvclientpool.AlwaysReachable().Do(req)
+			if err != nil {
+				t.Fatalf("history failed: %v", err)
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+				t.Fatalf("invalid history: %v", err)
+			}
+			if len(history.Versions) != len(tc.want) {
+				t.Fatalf("got %d versions, wanted %d: %+v", len(history.Versions), len(tc.want), history.Versions)
+			}
+			for i, got := range history.Versions {
+				want := tc.want[i]
+				if !bytes.Equal(got.Value, want.Value) || got.Origin != want.Origin ||
+					got.Superseded != want.Superseded || got.Dropped != want.Dropped {
+					t.Errorf("version %d = %+v, wanted %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
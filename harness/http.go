@@ -3,12 +3,17 @@ package harness
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
+
+	"github.com/spencer-p/okayv/auth"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 type nothing struct{}
@@ -21,6 +26,13 @@ type ClientPool struct {
 	recorder *Recorder
 	servers  map[string]http.Handler
 	topo     NetTopology
+	// scheme is the scheme nodes use to address each other.
+	scheme string
+	// signer signs the view changes sent by the pool.
+	signer auth.Signer
+
+	m         sync.Mutex
+	listeners map[string]*bufconn.Listener
 }
 
 type Client struct {
@@ -33,6 +45,9 @@ func NewClientPool(topo NetTopology, recorder *Recorder) *ClientPool {
 		recorder: recorder,
 		servers:  make(map[string]http.Handler),
 		topo:     topo,
+		scheme:   "http",
+
+		listeners: make(map[string]*bufconn.Listener),
 	}
 }
 
@@ -48,14 +63,14 @@ func (p *ClientPool) ViewChange(newnode string) error {
 	if len(p.servers) > 1 {
 		var addrs []string
 		for srv := range p.servers {
-			addrs = append(addrs, "http://"+srv)
+			addrs = append(addrs, p.scheme+"://"+srv)
 		}
 		// Choose a random server and send it the new view.
 		for name, handler := range p.servers {
 			if name == newnode {
 				continue // But not the one we just added.
 			}
-			if err := viewChange(name, handler, addrs); err != nil {
+			if err := viewChange(name, handler, addrs, p.signer); err != nil {
 				return err
 			}
 			break
@@ -98,7 +113,12 @@ func (p *ClientPool) AlwaysReachable() DoFunc {
 	}
 }
 
-func viewChange(name string, handler http.Handler, addrs []string) error {
+// SetSigner signs the view changes sent by the pool with signer.
+func (p *ClientPool) SetSigner(signer auth.Signer) {
+	p.signer = signer
+}
+
+func viewChange(name string, handler http.Handler, addrs []string, signer auth.Signer) error {
 	var body bytes.Buffer
 	req := map[string]any{
 		"replicas": addrs,
@@ -112,6 +132,11 @@ func viewChange(name string, handler http.Handler, addrs []string) error {
 		return err
 	}
 	httpreq.Header.Set("User-Agent", "test-harness")
+	if signer != nil {
+		if err := signer.Sign(httpreq, body.Bytes()); err != nil {
+			return err
+		}
+	}
 	recorder := httptest.NewRecorder()
 	handler.ServeHTTP(recorder, httpreq)
 	if recorder.Code != http.StatusOK {
@@ -127,6 +152,8 @@ type RecordedMessage struct {
 	request  string
 	response string
 	code     int
+	// status is the status of a gRPC response.
+	status string
 }
 
 type Recorder struct {
@@ -140,10 +167,24 @@ func (rec *Recorder) MakeHandler(inner http.Handler) http.Handler {
 		origin := r.Header.Get("User-Agent")
 		method := r.Method
 		var body []byte
-		bodycopy, err := r.GetBody()
-		if err == nil {
-			body, _ = io.ReadAll(bodycopy)
-			bodycopy.Close()
+		if r.GetBody != nil {
+			bodycopy, err := r.GetBody()
+			if err == nil {
+				body, _ = io.ReadAll(bodycopy)
+				bodycopy.Close()
+			}
+		}
+		if r.URL.Path == "/raw" {
+			// Binary requests are recorded as their JSON equivalent.
+			body, _ = json.Marshal(map[string]any{
+				"key":   r.URL.Query().Get("key"),
+				"value": body,
+			})
+		} else if key, ok := strings.CutPrefix(r.URL.Path, "/v1/kv/"); ok {
+			body, _ = json.Marshal(map[string]any{
+				"key":   key,
+				"value": body,
+			})
 		}
 
 		rec.m.Lock()
@@ -195,6 +236,8 @@ func (r *Recorder) ToSequence() string {
 		fmt.Fprintf(&buf, "\t%s->>%s: ", msg.src, msg.dst)
 		if msg.request != "" {
 			fmt.Fprintf(&buf, "%s %s%s", msg.method, msg.path, maybeKVString(msg.request))
+		} else if msg.status != "" {
+			fmt.Fprintf(&buf, "%s%s", msg.status, maybeKVString(msg.response))
 		} else {
 			fmt.Fprintf(&buf, "%d%s", msg.code, maybeKVString(msg.response))
 		}
@@ -222,9 +265,13 @@ func maybeKVString(in string) string {
 	if hasKey {
 		result += " " + key.(string)
 	}
-	value, hasValue := blob["value"]
+	value, hasValue := blob["value"].(string)
 	if hasValue {
-		result += "=" + value.(string)
+		raw, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			raw = []byte(value)
+		}
+		result += fmt.Sprintf("=%q", raw)
 	}
 	return result
 }
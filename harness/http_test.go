@@ -1,7 +1,10 @@
 package harness
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/spencer-p/okayv/auth"
 	"github.com/spencer-p/okayv/client"
 	"github.com/spencer-p/okayv/server"
 	"github.com/spencer-p/okayv/tsgen"
@@ -87,6 +91,17 @@ type MyImpl struct {
 	realclientpool map[string]*client.Client
 	Record         []any
 	writecount     int
+	// Increments holds the successful counter increments.
+	Increments []tsgen.Increment
+	// grpc makes nodes replicate over gRPC instead of HTTP.
+	grpc bool
+	// binary makes clients read and write through /v1/kv instead of the
+	// JSON /read and /write.
+	binary bool
+	// opts is the base of the options of every server.
+	opts server.Opts
+	// viewKeys holds the keys nodes sign their view changes with.
+	viewKeys map[string]ed25519.PrivateKey
 }
 
 var _ tsgen.Impl = &MyImpl{}
@@ -97,13 +112,23 @@ func (i *MyImpl) CreateNode(nodename string) error {
 	if err != nil {
 		return err
 	}
-	s := server.NewServer(mux, server.Opts{
-		Client:     cli,
-		Name:       nodename,
-		GossipFreq: 10 * time.Millisecond,
-	})
-	if err := i.srvclientpool.ViewChange(nodename); err != nil {
-		return err
+	opts := i.opts
+	opts.Client = cli
+	opts.Name = nodename
+	opts.GossipFreq = 10 * time.Millisecond
+	opts.ViewKey = i.viewKeys[nodename]
+	if i.grpc {
+		opts.GRPCDialOptions = i.srvclientpool.GRPCDialOptions(nodename)
+	}
+	s := server.NewServer(mux, opts)
+	if i.grpc {
+		i.srvclientpool.ServeGRPC(i.ctx, nodename, s)
+	}
+	// Nodes with seeds join the cluster themselves.
+	if len(opts.Seeds) == 0 {
+		if err := i.srvclientpool.ViewChange(nodename); err != nil {
+			return err
+		}
 	}
 	i.servers = append(i.servers, s)
 
@@ -113,7 +138,15 @@ func (i *MyImpl) CreateNode(nodename string) error {
 func (i *MyImpl) Read(clientname, node, key string) error {
 	c := i.realClient(clientname)
 	c.SetAddress("http://" + node)
-	result, err := c.Read(key)
+	var result string
+	var err error
+	if i.binary {
+		var buf []byte
+		buf, _, err = c.ReadBytes(key)
+		result = string(buf)
+	} else {
+		result, err = c.Read(key)
+	}
 	i.Record = append(i.Record, tsgen.ReadResult{
 		Client:   clientname,
 		Node:     node,
@@ -131,10 +164,43 @@ func (i *MyImpl) Read(clientname, node, key string) error {
 	return nil
 }
 
+func (i *MyImpl) ReadMany(clientname, node string, keys []string) error {
+	c := i.realClient(clientname)
+	c.SetAddress("http://" + node)
+	values, err := c.ReadMany(keys...)
+	result := tsgen.ReadManyResult{
+		Client: clientname,
+		Node:   node,
+		Error:  errors.Is(err, client.ErrUnavailable),
+	}
+	if err != nil && !result.Error {
+		return err
+	}
+	if !result.Error {
+		for _, key := range keys {
+			value, ok := values[key]
+			result.Reads = append(result.Reads, tsgen.ReadResult{
+				Client:   clientname,
+				Node:     node,
+				Key:      key,
+				Value:    value,
+				NotFound: !ok,
+			})
+		}
+	}
+	i.Record = append(i.Record, result)
+	return nil
+}
+
 func (i *MyImpl) Write(clientname, node, key, value string) error {
 	c := i.realClient(clientname)
 	c.SetAddress("http://" + node)
-	err := c.Write(key, value)
+	var err error
+	if i.binary {
+		err = c.WriteBytes(key, []byte(value), "")
+	} else {
+		err = c.Write(key, value)
+	}
 	if errors.Is(err, client.ErrUnavailable) {
 		return nil // Not a fatal error for test, but not a sucessful write.
 	} else if err != nil {
@@ -153,6 +219,70 @@ func (i *MyImpl) Write(clientname, node, key, value string) error {
 	return nil
 }
 
+func (i *MyImpl) Increment(clientname, node, key string, delta int64) error {
+	c := i.realClient(clientname)
+	c.SetAddress("http://" + node)
+	err := c.Increment(key, delta)
+	if errors.Is(err, client.ErrUnavailable) {
+		return nil // The increment was not applied.
+	} else if err != nil {
+		return err
+	}
+	i.Increments = append(i.Increments, tsgen.Increment{
+		Client: clientname,
+		Node:   node,
+		Key:    key,
+		Delta:  delta,
+	})
+	i.writecount += 1
+	return nil
+}
+
+// converge heals every partition and gossips until the counters of every
+// node are valid or it gives up. It returns the last validation error.
+func (i *MyImpl) converge(model tsgen.Model) error {
+	for a := range model.Nodes {
+		for b := range model.Nodes {
+			if a != b {
+				model.Connect(a, b)
+			}
+		}
+	}
+	var err error
+	for round := 0; round < 10*len(i.servers); round++ {
+		for _, s := range i.servers {
+			s.Gossip()
+		}
+		if err = tsgen.ValidateCounters(i.Increments, i.counters(model)); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// counters reads every incremented counter from every node with a client that
+// has no causal context.
+func (i *MyImpl) counters(model tsgen.Model) []tsgen.CounterResult {
+	keys := map[string]struct{}{}
+	for _, inc := range i.Increments {
+		keys[inc.Key] = struct{}{}
+	}
+	var results []tsgen.CounterResult
+	for node := range model.Nodes {
+		c := client.NewClient(i.srvclientpool.AlwaysReachable(), "checker", "http://"+node)
+		for key := range keys {
+			value, err := c.Counter(key)
+			results = append(results, tsgen.CounterResult{
+				Node:     node,
+				Key:      key,
+				Value:    value,
+				NotFound: err != nil,
+			})
+		}
+	}
+	return results
+}
+
 func (i *MyImpl) realClient(clientname string) *client.Client {
 	c, ok := i.realclientpool[clientname]
 	if ok {
@@ -164,6 +294,96 @@ func (i *MyImpl) realClient(clientname string) *client.Client {
 	return c
 }
 
+// newTestImpl creates an implementation with the given nodes, all in one view.
+func newTestImpl(t testing.TB, nodes ...string) (*MyImpl, tsgen.Model) {
+	return newTestImplWith(t, testImplConfig{}, nodes...)
+}
+
+// testImplConfig configures an implementation made by newTestImplWith.
+type testImplConfig struct {
+	// grpc routes clients and gossip through the gRPC API.
+	grpc bool
+	// binary makes clients read and write through /v1/kv.
+	binary bool
+	// opts is the base of the options of every server.
+	opts server.Opts
+	// viewKeys holds the keys nodes sign their view changes with.
+	viewKeys map[string]ed25519.PrivateKey
+	// signer signs the view changes the harness sends.
+	signer auth.Signer
+	// recorder records requests. A new one is used if it is nil.
+	recorder *Recorder
+}
+
+// newTestImplWith is newTestImpl with a config.
+func newTestImplWith(t testing.TB, cfg testImplConfig, nodes ...string) (*MyImpl, tsgen.Model) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if cfg.recorder == nil {
+		cfg.recorder = &Recorder{}
+	}
+	model := tsgen.NewModel()
+	impl := &MyImpl{
+		ctx:            ctx,
+		srvclientpool:  NewClientPool(model, cfg.recorder),
+		realclientpool: make(map[string]*client.Client),
+		grpc:           cfg.grpc,
+		binary:         cfg.binary,
+		opts:           cfg.opts,
+		viewKeys:       cfg.viewKeys,
+	}
+	if cfg.grpc {
+		impl.srvclientpool.UseGRPC()
+	}
+	if cfg.signer != nil {
+		impl.srvclientpool.SetSigner(cfg.signer)
+	}
+	for _, node := range nodes {
+		if err := (tsgen.RegisterNode{Node: node}).Apply(model, impl); err != nil {
+			t.Fatalf("failed to create node %s: %v", node, err)
+		}
+	}
+	return impl, model
+}
+
+// request sends a JSON request to a node, decodes the response into out if it
+// is not nil and returns the status code.
+func (i *MyImpl) request(t *testing.T, method, node, path string, in, out any) int {
+	t.Helper()
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			t.Fatalf("failed to encode request: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, "http://"+node+path, &body)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	resp, err := i.srvclientpool.AlwaysReachable().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("failed to decode %s response: %v", path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func (i *MyImpl) mustWrite(t *testing.T, clientname, node, namespace, key, value string) {
+	t.Helper()
+	c := i.realClient(clientname)
+	c.SetAddress("http://" + node)
+	c.SetNamespace(namespace)
+	if err := c.Write(key, value); err != nil {
+		t.Fatalf("%s write %s=%s to %s failed: %v", clientname, key, value, node, err)
+	}
+}
+
 func writeSequenceHTML(contents string) (string, error) {
 	w, err := os.CreateTemp("", "sequence-*.html")
 	if err != nil {
@@ -191,3 +411,22 @@ config:
 	w.Write([]byte("</pre></body></html>"))
 	return "file://" + w.Name(), nil
 }
+
+// writeStates dumps the debug state of each server, by name, to a JSON file.
+func writeStates(servers []*server.Server) (string, error) {
+	states := make(map[string]server.DebugState, len(servers))
+	for _, s := range servers {
+		states[s.Name] = s.DebugState(server.DebugQuery{})
+	}
+	w, err := os.CreateTemp("", "states-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer w.Close()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(states); err != nil {
+		return "", err
+	}
+	return "file://" + w.Name(), nil
+}
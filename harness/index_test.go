@@ -0,0 +1,60 @@
+package harness
+
+import (
+	"maps"
+	"net/http"
+	"testing"
+
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestSecondaryIndex(t *testing.T) {
+	impl, _ := newTestImpl(t, "a", "b")
+	ns := server.Namespace{Name: "docs", Indexes: []string{"user.email"}}
+
+	impl.mustWrite(t, "alice", "a", "", "ignored", `{"user": {"email": "a@example.com"}}`)
+	if code := impl.request(t, http.MethodPut, "a", "/namespaces", ns, nil); code != http.StatusOK {
+		t.Fatalf("create namespace failed with %d", code)
+	}
+	impl.mustWrite(t, "alice", "a", "docs", "k1", `{"user": {"email": "a@example.com"}}`)
+	impl.mustWrite(t, "alice", "a", "docs", "k2", `{"user": {"email": "a@example.com", "age": 3}}`)
+	impl.mustWrite(t, "alice", "a", "docs", "k3", `{"user": {"email": "b@example.com"}}`)
+	impl.mustWrite(t, "alice", "a", "docs", "k4", `not json`)
+
+	c := impl.realClient("alice")
+	query := func(node string, want map[string]string) {
+		t.Helper()
+		c.SetAddress("http://" + node)
+		got, err := c.Query("user.email", "a@example.com")
+		if err != nil {
+			t.Fatalf("query on %s failed: %v", node, err)
+		}
+		if !maps.Equal(got, want) {
+			t.Errorf("query on %s = %v, wanted %v", node, got, want)
+		}
+	}
+
+	query("a", map[string]string{
+		"k1": `{"user": {"email": "a@example.com"}}`,
+		"k2": `{"user": {"email": "a@example.com", "age": 3}}`,
+	})
+
+	// Overwriting a key removes it from its old entry.
+	impl.mustWrite(t, "alice", "a", "docs", "k1", `{"user": {"email": "c@example.com"}}`)
+	query("a", map[string]string{
+		"k2": `{"user": {"email": "a@example.com", "age": 3}}`,
+	})
+
+	// Replicated columns are indexed too.
+	for _, s := range impl.servers {
+		s.Gossip()
+	}
+	query("b", map[string]string{
+		"k2": `{"user": {"email": "a@example.com", "age": 3}}`,
+	})
+
+	unindexed := server.Query{Namespace: "docs", Path: "user.age", Value: []byte("3")}
+	if code := impl.request(t, http.MethodPost, "a", "/query", unindexed, nil); code != http.StatusBadRequest {
+		t.Errorf("query of unindexed path returned %d, wanted %d", code, http.StatusBadRequest)
+	}
+}
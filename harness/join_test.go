@@ -0,0 +1,135 @@
+package harness
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/okayv/server"
+	"github.com/spencer-p/okayv/tsgen"
+)
+
+// newSeededImpl creates an implementation whose nodes know only their seeds.
+func newSeededImpl(t *testing.T, seeds []string, nodes ...string) (*MyImpl, tsgen.Model) {
+	return newTestImplWith(t, testImplConfig{opts: server.Opts{Seeds: seeds}}, nodes...)
+}
+
+func TestJoin(t *testing.T) {
+	impl, _ := newSeededImpl(t, []string{"http://a", "http://b"}, "a", "b", "c", "d", "e")
+	servers := impl.servers
+
+	// Every node joins at once, the later ones through either seed.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		wg.Add(1)
+		go func(s *server.Server) {
+			defer wg.Done()
+			if err := s.Join(ctx); err != nil {
+				t.Errorf("%s failed to join: %v", s.Name, err)
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	want := []string{"http://a", "http://b", "http://c", "http://d", "http://e"}
+	for _, s := range servers {
+		if view := s.Status().View; !slices.Equal(view, want) {
+			t.Errorf("%s has view %v, wanted %v", s.Name, view, want)
+		}
+	}
+
+	// The cluster replicates without a view change.
+	impl.mustWrite(t, "alice", "c", "", "k", "v")
+	for i := 0; i < 20; i++ {
+		for _, s := range servers {
+			s.Gossip()
+		}
+	}
+	var out server.KV
+	if code := impl.request(t, http.MethodGet, "e", "/read", server.KV{Key: "k"}, &out); code != http.StatusOK || string(out.Value) != "v" {
+		t.Errorf("read k on e = %d %q, wanted v", code, out.Value)
+	}
+}
+
+func TestJoinUnreachableSeed(t *testing.T) {
+	impl, model := newSeededImpl(t, []string{"http://a"}, "a", "b")
+	servers := impl.servers
+	a, b := servers[0], servers[1]
+	if err := a.Join(context.Background()); err != nil {
+		t.Fatalf("seed failed to join itself: %v", err)
+	}
+
+	model.Partition("a", "b")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := b.Join(ctx); err == nil {
+		t.Errorf("b joined a partitioned seed")
+	}
+	if view := b.Status().View; len(view) != 0 {
+		t.Errorf("b has view %v without joining", view)
+	}
+
+	model.Connect("a", "b")
+	if err := b.Join(context.Background()); err != nil {
+		t.Fatalf("b failed to join: %v", err)
+	}
+	for _, s := range servers {
+		if view := s.Status().View; !slices.Equal(view, []string{"http://a", "http://b"}) {
+			t.Errorf("%s has view %v after b joined", s.Name, view)
+		}
+	}
+}
+
+func TestJoinSigned(t *testing.T) {
+	nodes := []string{"a", "b", "c"}
+	privs := make(map[string]ed25519.PrivateKey)
+	pubs := make(map[string]ed25519.PublicKey)
+	for _, node := range nodes {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[node], pubs[node] = priv, pub
+	}
+	impl, _ := newTestImplWith(t, testImplConfig{
+		viewKeys: privs,
+		opts:     server.Opts{Seeds: []string{"http://a"}, ViewKeys: pubs},
+	}, nodes...)
+	for _, s := range impl.servers {
+		if err := s.Join(context.Background()); err != nil {
+			t.Fatalf("%s failed to join: %v", s.Name, err)
+		}
+	}
+
+	// Every view a replica took from a peer was signed by its origin.
+	want := []string{"http://a", "http://b", "http://c"}
+	for _, s := range impl.servers {
+		if view := s.Status().View; !slices.Equal(view, want) {
+			t.Errorf("%s has view %v, wanted %v", s.Name, view, want)
+		}
+		for _, e := range s.AuditEntries() {
+			if e.Error != "" || !e.Signed {
+				t.Errorf("%s audited %+v, wanted a signed change", s.Name, e)
+			}
+		}
+	}
+
+	// A change its origin did not sign is refused.
+	forged := server.JoinRequest{Change: &server.ViewChange{
+		Replicas: append(want, "http://mallory"),
+		Origin:   "a",
+		Issued:   time.Now().UTC(),
+	}}
+	if code := impl.request(t, http.MethodPost, "b", "/join", forged, nil); code != http.StatusForbidden {
+		t.Errorf("forged join returned %d, wanted %d", code, http.StatusForbidden)
+	}
+	if view := impl.servers[1].Status().View; !slices.Equal(view, want) {
+		t.Errorf("b has view %v after a forged join", view)
+	}
+}
@@ -0,0 +1,47 @@
+package harness
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestReplicationLag(t *testing.T) {
+	impl, model := newTestImpl(t, "a", "b")
+	a, b := impl.servers[0], impl.servers[1]
+	impl.mustWrite(t, "alice", "a", "", "k1", "v")
+	a.Gossip()
+
+	model.Partition("a", "b")
+	impl.mustWrite(t, "alice", "a", "", "k2", "v")
+	a.Gossip()
+	var lags []server.PeerLag
+	if code := impl.request(t, http.MethodGet, "a", "/replication-lag", nil, &lags); code != http.StatusOK {
+		t.Fatalf("GET /replication-lag = %d", code)
+	}
+	if len(lags) != 1 || lags[0].Peer != "b" {
+		t.Fatalf("lags = %+v, wanted one for b", lags)
+	}
+	lag := lags[0]
+	if lag.Unreplicated != 1 || lag.OldestUnreplicated <= 0 {
+		t.Errorf("b has %d unreplicated events, oldest %v, wanted one", lag.Unreplicated, lag.OldestUnreplicated)
+	}
+	if lag.LastGossip.IsZero() {
+		t.Errorf("a never gossiped with b")
+	}
+	if lag.Ahead["a"] != 1 || len(lag.Behind) != 0 {
+		t.Errorf("a is %v ahead of and %v behind b, wanted one event ahead", lag.Ahead, lag.Behind)
+	}
+
+	model.Connect("a", "b")
+	a.Gossip()
+	b.Gossip()
+	for _, s := range impl.servers {
+		for _, lag := range s.ReplicationLag() {
+			if lag.Unreplicated != 0 || len(lag.Ahead) != 0 || len(lag.Behind) != 0 {
+				t.Errorf("%s has lag %+v with %s after gossip", s.Name, lag, lag.Peer)
+			}
+		}
+	}
+}
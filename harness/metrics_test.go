@@ -0,0 +1,84 @@
+package harness
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMetrics(t *testing.T) {
+	impl, _ := newTestImpl(t, "a", "b")
+	impl.mustWrite(t, "alice", "a", "", "k", "v")
+	a, b := impl.servers[0], impl.servers[1]
+	a.Gossip()
+	b.Gossip()
+
+	gathered, err := a.Metrics().Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	families := make(map[string]*dto.MetricFamily)
+	for _, f := range gathered {
+		families[f.GetName()] = f
+	}
+	sum := func(name string) float64 {
+		t.Helper()
+		f, ok := families[name]
+		if !ok {
+			t.Fatalf("no metric %s", name)
+		}
+		total := 0.0
+		for _, m := range f.GetMetric() {
+			switch {
+			case m.Counter != nil:
+				total += m.Counter.GetValue()
+			case m.Gauge != nil:
+				total += m.Gauge.GetValue()
+			}
+		}
+		return total
+	}
+
+	if got := sum("okayv_requests_total"); got == 0 {
+		t.Errorf("a counted no requests")
+	}
+	if got := sum("okayv_gossip_rounds_total"); got == 0 {
+		t.Errorf("a counted no gossip rounds")
+	}
+	if got := sum("okayv_gossip_columns_sent_total"); got == 0 {
+		t.Errorf("a counted no columns sent")
+	}
+	if got := sum("okayv_events"); got != 1 {
+		t.Errorf("a has %v events, wanted 1", got)
+	}
+	if got := sum("okayv_unacked_events"); got != 0 {
+		t.Errorf("a has %v unacked events after gossip, wanted 0", got)
+	}
+
+	impl.mustWrite(t, "alice", "a", "", "k2", "v")
+	req, err := http.NewRequest(http.MethodGet, "http://a/metrics", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	resp, err := impl.srvclientpool.AlwaysReachable().Do(req)
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", resp.StatusCode)
+	}
+	if !strings.Contains(string(body), `okayv_unacked_events{peer="b"} 1`) {
+		t.Errorf("metrics do not show one event unacked by b:\n%s", body)
+	}
+	if !strings.Contains(string(body), `okayv_requests_total{code="200",route="/write"}`) {
+		t.Errorf("metrics do not count writes:\n%s", body)
+	}
+}
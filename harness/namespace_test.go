@@ -0,0 +1,206 @@
+package harness
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestNamespaces(t *testing.T) {
+	impl, model := newTestImpl(t, "a", "b")
+	c := impl.realClient("alice")
+	c.SetAddress("http://a")
+
+	for _, ns := range []server.Namespace{
+		{Name: "team"},
+		{Name: "sib", Policy: server.PolicySiblings},
+		{Name: "ttl", TTL: server.Duration(50 * time.Millisecond)},
+		{Name: "durable", ReplicationFactor: 2},
+	} {
+		if code := impl.request(t, http.MethodPut, "a", "/namespaces", ns, nil); code != http.StatusOK {
+			t.Fatalf("create namespace %q failed with %d", ns.Name, code)
+		}
+	}
+	var list []server.Namespace
+	impl.request(t, http.MethodGet, "b", "/namespaces", nil, &list)
+	if len(list) != 5 {
+		t.Errorf("b has namespaces %+v, wanted the default and four forwarded ones", list)
+	}
+
+	t.Run("isolation", func(t *testing.T) {
+		c.SetNamespace("")
+		if err := c.Write("x", "default"); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		c.SetNamespace("team")
+		if err := c.Write("x", "team"); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		for ns, want := range map[string]string{"": "default", "team": "team"} {
+			c.SetNamespace(ns)
+			if got, err := c.Read("x"); err != nil || got != want {
+				t.Errorf("read x in %q = %q, %v, wanted %q", ns, got, err, want)
+			}
+		}
+		c.SetNamespace("missing")
+		if _, err := c.Read("x"); !errors.Is(err, client.ErrNotFound) {
+			t.Errorf("read in missing namespace returned %v, wanted %v", err, client.ErrNotFound)
+		}
+	})
+
+	t.Run("siblings", func(t *testing.T) {
+		model.Partition("a", "b")
+		impl.mustWrite(t, "alice", "a", "sib", "x", "1")
+		impl.mustWrite(t, "bob", "b", "sib", "x", "2")
+		model.Connect("a", "b")
+		for _, s := range impl.servers {
+			s.Gossip()
+		}
+
+		for _, node := range []string{"a", "b"} {
+			var got server.KV
+			impl.request(t, http.MethodGet, node, "/read", server.KV{Namespace: "sib", Key: "x"}, &got)
+			if len(got.Siblings) != 2 || string(got.Value) != "2" {
+				t.Errorf("read on %s = %q with siblings %q, wanted 2 with two siblings", node, got.Value, got.Siblings)
+			}
+		}
+
+		// A later write resolves the siblings.
+		impl.mustWrite(t, "alice", "a", "sib", "x", "3")
+		var got server.KV
+		impl.request(t, http.MethodGet, "a", "/read", server.KV{Namespace: "sib", Key: "x"}, &got)
+		if len(got.Siblings) != 0 || string(got.Value) != "3" {
+			t.Errorf("read = %q with siblings %q, wanted 3 without siblings", got.Value, got.Siblings)
+		}
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		c.SetNamespace("ttl")
+		if err := c.Write("x", "1"); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		if got, err := c.Read("x"); err != nil || got != "1" {
+			t.Errorf("read = %q, %v, wanted 1", got, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if _, err := c.Read("x"); !errors.Is(err, client.ErrNotFound) {
+			t.Errorf("read after expiry returned %v, wanted %v", err, client.ErrNotFound)
+		}
+	})
+
+	t.Run("replication factor", func(t *testing.T) {
+		write := server.KV{Namespace: "durable", Key: "x", Value: []byte("1")}
+		if code := impl.request(t, http.MethodPut, "a", "/write", write, nil); code != http.StatusOK {
+			t.Errorf("connected write returned %d, wanted %d", code, http.StatusOK)
+		}
+		model.Partition("a", "b")
+		write.Value = []byte("2")
+		var out server.KV
+		if code := impl.request(t, http.MethodPut, "a", "/write", write, &out); code != http.StatusAccepted {
+			t.Errorf("partitioned write returned %d, wanted %d", code, http.StatusAccepted)
+		}
+		if !out.Pending || out.Replicas != 1 || out.Context == nil {
+			t.Errorf("partitioned write = %+v, wanted a pending write on one replica", out)
+		}
+
+		// The client reports pending writes, which it can still read.
+		c.SetNamespace("durable")
+		if err := c.Write("x", "3"); !errors.Is(err, client.ErrPending) {
+			t.Errorf("partitioned client write returned %v, wanted %v", err, client.ErrPending)
+		}
+		if got, err := c.Read("x"); err != nil || got != "3" {
+			t.Errorf("read after pending write = %q, %v, wanted 3", got, err)
+		}
+		if err := c.WriteBytes("y", []byte("4"), ""); !errors.Is(err, client.ErrPending) {
+			t.Errorf("partitioned WriteBytes returned %v, wanted %v", err, client.ErrPending)
+		}
+		if err := c.Delete("y"); !errors.Is(err, client.ErrPending) {
+			t.Errorf("partitioned Delete returned %v, wanted %v", err, client.ErrPending)
+		}
+		model.Connect("a", "b")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		del := server.Namespace{Name: "team"}
+		if code := impl.request(t, http.MethodDelete, "a", "/namespaces", del, nil); code != http.StatusOK {
+			t.Fatalf("delete failed with %d", code)
+		}
+		for _, node := range []string{"a", "b"} {
+			code := impl.request(t, http.MethodGet, node, "/read", server.KV{Namespace: "team", Key: "x"}, nil)
+			if code != http.StatusNotFound {
+				t.Errorf("read on %s after delete returned %d, wanted %d", node, code, http.StatusNotFound)
+			}
+		}
+	})
+}
+
+func TestNamespaceGossip(t *testing.T) {
+	impl, model := newTestImpl(t, "a", "b", "c")
+	gossip := func() {
+		for i := 0; i < 5; i++ {
+			for _, s := range impl.servers {
+				s.Gossip()
+			}
+		}
+	}
+	names := func(node string) map[string]bool {
+		var list []server.Namespace
+		impl.request(t, http.MethodGet, node, "/namespaces", nil, &list)
+		result := map[string]bool{}
+		for _, ns := range list {
+			result[ns.Name] = true
+		}
+		return result
+	}
+
+	// c misses the change while partitioned and learns it through gossip.
+	model.Partition("a", "c")
+	model.Partition("b", "c")
+	if code := impl.request(t, http.MethodPut, "a", "/namespaces", server.Namespace{Name: "team"}, nil); code != http.StatusOK {
+		t.Fatalf("create namespace failed with %d", code)
+	}
+	if names("c")["team"] {
+		t.Fatalf("partitioned c has the namespace")
+	}
+	model.Connect("a", "c")
+	model.Connect("b", "c")
+	gossip()
+	if !names("c")["team"] {
+		t.Errorf("c did not learn the namespace through gossip")
+	}
+
+	// A write on b that races the deletion on a does not restore its key.
+	impl.mustWrite(t, "alice", "a", "team", "x", "1")
+	gossip()
+	model.Partition("a", "b")
+	model.Partition("a", "c")
+	if code := impl.request(t, http.MethodDelete, "a", "/namespaces", server.Namespace{Name: "team"}, nil); code != http.StatusOK {
+		t.Fatalf("delete namespace failed with %d", code)
+	}
+	impl.mustWrite(t, "bob", "b", "team", "y", "2")
+	model.Connect("a", "b")
+	model.Connect("a", "c")
+	gossip()
+	for _, node := range []string{"a", "b", "c"} {
+		if names(node)["team"] {
+			t.Errorf("%s still has the deleted namespace", node)
+		}
+	}
+
+	if code := impl.request(t, http.MethodPut, "b", "/namespaces", server.Namespace{Name: "team"}, nil); code != http.StatusOK {
+		t.Fatalf("recreate namespace failed with %d", code)
+	}
+	gossip()
+	for _, node := range []string{"a", "b", "c"} {
+		for _, key := range []string{"x", "y"} {
+			code := impl.request(t, http.MethodGet, node, "/read", server.KV{Namespace: "team", Key: key}, nil)
+			if code != http.StatusNotFound {
+				t.Errorf("read %s on %s in the recreated namespace returned %d, wanted %d", key, node, code, http.StatusNotFound)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package harness
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestRESTResource(t *testing.T) {
+	impl, _ := newTestImpl(t, "a", "b")
+	do := func(method, node, key, body string, header http.Header) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, "http://"+node+"/v1/kv/"+key, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to build request: %v", err)
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp, err := impl.srvclientpool.AlwaysReachable().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, key, err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	put := do(http.MethodPut, "a", "x", "1", http.Header{"Content-Type": {"text/plain"}})
+	if put.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT returned %d, wanted %d", put.StatusCode, http.StatusNoContent)
+	}
+	etag := put.Header.Get("ETag")
+	ctx := put.Header.Get(server.ContextHeader)
+	if etag == "" || ctx == "" {
+		t.Fatalf("PUT returned ETag %q and context %q, wanted both", etag, ctx)
+	}
+
+	get := do(http.MethodGet, "a", "x", "", nil)
+	if body, _ := io.ReadAll(get.Body); get.StatusCode != http.StatusOK || string(body) != "1" {
+		t.Errorf("GET returned %d %q, wanted 200 1", get.StatusCode, body)
+	}
+	if got := get.Header.Get("ETag"); got != etag {
+		t.Errorf("GET returned ETag %s, wanted %s", got, etag)
+	}
+	if got := do(http.MethodGet, "a", "x", "", http.Header{"If-None-Match": {etag}}); got.StatusCode != http.StatusNotModified {
+		t.Errorf("conditional GET returned %d, wanted %d", got.StatusCode, http.StatusNotModified)
+	}
+
+	// Writes conditioned on a stale version fail.
+	if got := do(http.MethodPut, "a", "x", "2", http.Header{"If-Match": {etag}}); got.StatusCode != http.StatusNoContent {
+		t.Errorf("PUT with current If-Match returned %d, wanted %d", got.StatusCode, http.StatusNoContent)
+	}
+	if got := do(http.MethodPut, "a", "x", "3", http.Header{"If-Match": {etag}}); got.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("PUT with stale If-Match returned %d, wanted %d", got.StatusCode, http.StatusPreconditionFailed)
+	}
+	if got := do(http.MethodDelete, "a", "x", "", http.Header{"If-Match": {etag}}); got.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with stale If-Match returned %d, wanted %d", got.StatusCode, http.StatusPreconditionFailed)
+	}
+
+	post := do(http.MethodPost, "a", "x", "", nil)
+	if post.StatusCode != http.StatusMethodNotAllowed || post.Header.Get("Allow") != "DELETE, GET, PUT" {
+		t.Errorf("POST returned %d with Allow %q, wanted 405 with DELETE, GET, PUT", post.StatusCode, post.Header.Get("Allow"))
+	}
+
+	// Deletes replicate as tombstones.
+	c := impl.realClient("alice")
+	c.SetAddress("http://a")
+	if err := c.Delete("x"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := c.Delete("x"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("second Delete returned %v, wanted %v", err, client.ErrNotFound)
+	}
+	for _, s := range impl.servers {
+		s.Gossip()
+	}
+	c.SetAddress("http://b")
+	if _, _, err := c.ReadBytes("x"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("read of deleted key on b returned %v, wanted %v", err, client.ErrNotFound)
+	}
+	if _, err := c.Read("x"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("legacy read of deleted key on b returned %v, wanted %v", err, client.ErrNotFound)
+	}
+
+	// A key can be written again after it is deleted.
+	if err := c.WriteBytes("x", []byte("4"), ""); err != nil {
+		t.Fatalf("write after delete failed: %v", err)
+	}
+	if got, _, err := c.ReadBytes("x"); err != nil || string(got) != "4" {
+		t.Errorf("read after rewrite = %q, %v, wanted 4", got, err)
+	}
+}
+
+func TestRESTKeys(t *testing.T) {
+	impl, _ := newTestImpl(t, "a")
+	c := impl.realClient("alice")
+	c.SetAddress("http://a")
+
+	keys := []string{"a//b", ".", "..", "x/./y", "x/../y", "/lead", "trail/", "100%", "%2F"}
+	for _, key := range keys {
+		if err := c.WriteBytes(key, []byte(key), ""); err != nil {
+			t.Errorf("write %q failed: %v", key, err)
+		}
+	}
+	for _, key := range keys {
+		if got, _, err := c.ReadBytes(key); err != nil || string(got) != key {
+			t.Errorf("read %q = %q, %v, wanted itself", key, got, err)
+		}
+	}
+	if err := c.Delete("a//b"); err != nil {
+		t.Errorf("delete failed: %v", err)
+	}
+	if got, _, err := c.ReadBytes("a/b"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("read a/b = %q, %v, wanted it to be distinct from a//b", got, err)
+	}
+
+	// Keys are encoded once, so plain HTTP clients can name them.
+	for path, want := range map[string]string{
+		"trail%2F":   "trail/",
+		"x%2F..%2Fy": "x/../y",
+		"%252F":      "%2F",
+		"100%25":     "100%",
+		"%2E%2E":     "..",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "http://a/v1/kv/"+path, nil)
+		resp, err := impl.srvclientpool.AlwaysReachable().Do(req)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != want {
+			t.Errorf("GET %s = %d %q, wanted %q", path, resp.StatusCode, body, want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package harness
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+func TestScan(t *testing.T) {
+	impl, _ := newTestImpl(t, "a", "b")
+	for _, key := range []string{"user/1", "user/2", "user/3", "order/1"} {
+		impl.mustWrite(t, "alice", "a", "", key, "v"+key)
+	}
+	c := impl.realClient("alice")
+	c.SetAddress("http://a")
+	if err := c.Delete("user/2"); err != nil {
+		t.Fatalf("failed to delete user/2: %v", err)
+	}
+
+	got, err := c.Scan("user/", 0)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	want := []client.KeyValue{{Key: "user/1", Value: "vuser/1"}, {Key: "user/3", Value: "vuser/3"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("scan user/ = %v, wanted %v", got, want)
+	}
+	if got, err := c.Scan("", 2); err != nil || len(got) != 2 || got[0].Key != "order/1" {
+		t.Errorf("scan with limit 2 = %v, %v", got, err)
+	}
+
+	// Pages continue after the last key.
+	var page server.ScanResult
+	if code := impl.request(t, http.MethodPost, "a", "/scan", server.Scan{Limit: 1, After: "order/1"}, &page); code != http.StatusOK {
+		t.Fatalf("POST /scan = %d", code)
+	}
+	if len(page.Results) != 1 || page.Results[0].Key != "user/1" || !page.More {
+		t.Errorf("page after order/1 = %+v", page)
+	}
+
+	// A scan waits for the writes the client has seen.
+	c.SetAddress("http://b")
+	if _, err := c.Scan("user/", 0); err != client.ErrUnavailable {
+		t.Errorf("scan of a replica behind the client = %v, wanted %v", err, client.ErrUnavailable)
+	}
+}
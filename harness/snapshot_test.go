@@ -0,0 +1,87 @@
+package harness
+
+import "testing"
+
+func TestReadAt(t *testing.T) {
+	impl, _ := newTestImpl(t, "a")
+	c := impl.realClient("alice")
+	c.SetAddress("http://a")
+
+	for _, kv := range [][2]string{{"x", "1"}, {"y", "1"}} {
+		if err := c.Write(kv[0], kv[1]); err != nil {
+			t.Fatalf("write %s failed: %v", kv[0], err)
+		}
+	}
+	snapshot := c.Context()
+	if err := c.Write("x", "2"); err != nil {
+		t.Fatalf("write x failed: %v", err)
+	}
+
+	table := []struct {
+		key  string
+		at   any
+		want string
+	}{
+		{key: "x", at: snapshot, want: "1"},
+		{key: "y", at: snapshot, want: "1"},
+		{key: "x", at: c.Context(), want: "2"},
+	}
+	for _, tc := range table {
+		got, err := c.ReadAt(tc.key, tc.at)
+		if err != nil {
+			t.Errorf("ReadAt(%s, %v) failed: %v", tc.key, tc.at, err)
+		} else if got != tc.want {
+			t.Errorf("ReadAt(%s, %v) = %s, wanted %s", tc.key, tc.at, got, tc.want)
+		}
+	}
+
+	if got, err := c.Read("x"); err != nil || got != "2" {
+		t.Errorf("Read(x) = %s, %v, wanted 2", got, err)
+	}
+}
+
+func TestReadAtOtherReplica(t *testing.T) {
+	impl, _ := newTestImpl(t, "a", "b")
+	gossip := func() {
+		for i := 0; i < 5; i++ {
+			for _, s := range impl.servers {
+				s.Gossip()
+			}
+		}
+	}
+	alice := impl.realClient("alice")
+	alice.SetAddress("http://a")
+	for _, key := range []string{"x", "y"} {
+		if err := alice.Write(key, "1"); err != nil {
+			t.Fatalf("write %s failed: %v", key, err)
+		}
+	}
+	gossip()
+
+	// Bob's cut comes from b, which logged the writes with its own clock.
+	bob := impl.realClient("bob")
+	bob.SetAddress("http://b")
+	if _, err := bob.Read("y"); err != nil {
+		t.Fatalf("read y on b failed: %v", err)
+	}
+	fromA, fromB := alice.Context(), bob.Context()
+	if err := alice.Write("x", "2"); err != nil {
+		t.Fatalf("write x failed: %v", err)
+	}
+	gossip()
+
+	for _, tc := range []struct {
+		node string
+		at   any
+	}{
+		{"a", fromA}, {"b", fromA}, {"a", fromB}, {"b", fromB},
+	} {
+		c := impl.realClient("carol-" + tc.node)
+		c.SetAddress("http://" + tc.node)
+		for _, key := range []string{"x", "y"} {
+			if got, err := c.ReadAt(key, tc.at); err != nil || got != "1" {
+				t.Errorf("ReadAt(%s, %v) on %s = %s, %v, wanted 1", key, tc.at, tc.node, got, err)
+			}
+		}
+	}
+}
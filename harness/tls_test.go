@@ -0,0 +1,294 @@
+package harness
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/spencer-p/okayv/auth"
+	"github.com/spencer-p/okayv/client"
+	"github.com/spencer-p/okayv/server"
+)
+
+// testCA signs certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "okayv test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// issue writes a certificate for name and its key to dir, and returns their
+// paths.
+func (ca *testCA) issue(t *testing.T, dir, name string, serial int64) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// tlsNet resolves test host names to local TLS servers.
+type tlsNet map[string]string
+
+func (n tlsNet) client(t *testing.T, ca *testCA, certs *server.CertReloader) *http.Client {
+	cfg := &tls.Config{RootCAs: ca.pool}
+	if certs != nil {
+		cfg = server.ClientTLSConfig(certs, ca.pool)
+	}
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig: cfg,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, _ := net.SplitHostPort(addr)
+			var d net.Dialer
+			return d.DialContext(ctx, network, n[host])
+		},
+	}}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	network := tlsNet{}
+	servers := map[string]*server.Server{}
+	reloaders := map[string]*server.CertReloader{}
+	for i, name := range []string{"a.test", "b.test"} {
+		certFile, keyFile := ca.issue(t, dir, name, int64(10+i))
+		certs, err := server.NewCertReloader(certFile, keyFile)
+		if err != nil {
+			t.Fatalf("failed to load certificate: %v", err)
+		}
+		reloaders[name] = certs
+		mux := http.NewServeMux()
+		servers[name] = server.NewServer(mux, server.Opts{
+			Logger:   log.New(io.Discard),
+			Name:     name,
+			Client:   network.client(t, ca, certs),
+			PeerAuth: true,
+		})
+		ts := httptest.NewUnstartedServer(mux)
+		ts.TLS = server.ServerTLSConfig(certs, ca.pool)
+		ts.StartTLS()
+		t.Cleanup(ts.Close)
+		network[name] = ts.Listener.Addr().String()
+	}
+
+	// Peers send the view to each other with their certificates.
+	a := network.client(t, ca, reloaders["a.test"])
+	view, _ := json.Marshal(map[string]any{"replicas": []string{"https://a.test", "https://b.test"}})
+	req, _ := http.NewRequest(http.MethodPut, "https://a.test/view-change", bytes.NewReader(view))
+	resp, err := a.Do(req)
+	if err != nil {
+		t.Fatalf("view change failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("view change returned %d", resp.StatusCode)
+	}
+
+	// Clients need no certificate.
+	alice := client.NewClient(network.client(t, ca, nil), "alice", "https://a.test")
+	if err := alice.Write("x", "1"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	servers["a.test"].Gossip()
+	alice.SetAddress("https://b.test")
+	if got, err := alice.Read("x"); err != nil || got != "1" {
+		t.Errorf("Read(x) on b = %q, %v, wanted 1", got, err)
+	}
+
+	// A certificate from the same CA for a host outside the view is refused,
+	// as is gossip without a certificate.
+	certFile, keyFile := ca.issue(t, dir, "mallory.test", 99)
+	mallory, err := server.NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name   string
+		client *http.Client
+		want   int
+	}{
+		{"outsider", network.client(t, ca, mallory), http.StatusForbidden},
+		{"anonymous", network.client(t, ca, nil), http.StatusUnauthorized},
+		{"peer", network.client(t, ca, reloaders["a.test"]), http.StatusOK},
+	} {
+		body, _ := json.Marshal(server.Gossip{Host: "a.test"})
+		req, _ := http.NewRequest(http.MethodPut, "https://b.test/gossip", bytes.NewReader(body))
+		resp, err := tc.client.Do(req)
+		if err != nil {
+			t.Fatalf("%s: gossip failed: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: gossip returned %d, wanted %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+
+	// A forwarded view change must come from a peer in the view.
+	fwd, _ := json.Marshal(server.ViewChange{
+		Replicas:     []string{"https://b.test", "https://mallory.test"},
+		DoNotForward: true,
+	})
+	req, _ = http.NewRequest(http.MethodPut, "https://b.test/view-change", bytes.NewReader(fwd))
+	resp, err = network.client(t, ca, mallory).Do(req)
+	if err != nil {
+		t.Fatalf("view change failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("view change from an outsider returned %d, wanted %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	// A new certificate is served without a restart once it is noticed.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloaders["a.test"].Watch(ctx, 10*time.Millisecond)
+	issued, _ := ca.issue(t, dir, "a.test", 42)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(issued, later, later); err != nil {
+		t.Fatal(err)
+	}
+	var serial int64
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		conn, err := tls.Dial("tcp", network["a.test"], &tls.Config{RootCAs: ca.pool, ServerName: "a.test"})
+		if err != nil {
+			t.Fatalf("failed to dial a: %v", err)
+		}
+		serial = conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+		conn.Close()
+		if serial == 42 {
+			break
+		}
+	}
+	if serial != 42 {
+		t.Errorf("a served certificate %v after reload, wanted 42", serial)
+	}
+}
+
+func TestMutualTLSAdmin(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	network := tlsNet{}
+	certFile, keyFile := ca.issue(t, dir, "a.test", 10)
+	certs, err := server.NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("failed to load certificate: %v", err)
+	}
+	mux := http.NewServeMux()
+	server.NewServer(mux, server.Opts{
+		Logger:        log.New(io.Discard),
+		Name:          "a.test",
+		Client:        network.client(t, ca, certs),
+		PeerAuth:      true,
+		Authenticator: auth.Tokens{"root-token": "root", "alice-token": "alice"},
+		ACL:           &auth.ACL{Admins: []string{"root"}},
+	})
+	ts := httptest.NewUnstartedServer(mux)
+	ts.TLS = server.ServerTLSConfig(certs, ca.pool)
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	network["a.test"] = ts.Listener.Addr().String()
+
+	// A node without a view takes forwarded changes only from a replica in
+	// the view it is sent.
+	certFile, keyFile = ca.issue(t, dir, "mallory.test", 99)
+	mallory, err := server.NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fwd, _ := json.Marshal(server.ViewChange{Replicas: []string{"https://a.test"}, DoNotForward: true})
+	req, _ := http.NewRequest(http.MethodPut, "https://a.test/view-change", bytes.NewReader(fwd))
+	req.Header.Set("Authorization", "Bearer root-token")
+	resp, err := network.client(t, ca, mallory).Do(req)
+	if err != nil {
+		t.Fatalf("view change failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("forwarded view change from an outsider returned %d, wanted %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	// Admins change the view through the ACL, without a certificate.
+	for _, tc := range []struct {
+		token string
+		fail  bool
+	}{
+		{"alice-token", true},
+		{"root-token", false},
+	} {
+		c := client.NewClient(network.client(t, ca, nil), "admin", "https://a.test")
+		c.SetSigner(auth.BearerToken(tc.token))
+		if err := c.ViewChange([]string{"https://a.test"}); (err != nil) != tc.fail {
+			t.Errorf("view change with %s = %v, wanted failure %v", tc.token, err, tc.fail)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/spencer-p/okayv/server"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	for _, useGRPC := range []bool{false, true} {
+		t.Run(fmt.Sprintf("grpc=%v", useGRPC), func(t *testing.T) {
+			testTracing(t, useGRPC)
+		})
+	}
+}
+
+func testTracing(t *testing.T, useGRPC bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	impl, _ := newTestImplWith(t, testImplConfig{
+		grpc: useGRPC,
+		opts: server.Opts{TracerProvider: tp},
+	}, "a", "b")
+
+	rootctx, root := tp.Tracer("test").Start(ctx, "client")
+	c := impl.realClient("alice")
+	c.SetTraceContext(rootctx)
+	impl.mustWrite(t, "alice", "a", "", "k", "v")
+	root.End()
+	c.SetTraceContext(nil)
+	a, b := impl.servers[0], impl.servers[1]
+	a.Gossip()
+	b.Gossip()
+
+	traceID := root.SpanContext().TraceID()
+	spans := exporter.GetSpans()
+	find := func(name, replica string) (tracetest.SpanStub, bool) {
+		for _, span := range spans {
+			if span.Name == name && hasAttribute(span.Attributes, "okayv.replica", replica) {
+				return span, true
+			}
+		}
+		return tracetest.SpanStub{}, false
+	}
+
+	write, ok := find("/write", "a")
+	if !ok {
+		t.Fatalf("no span of the write on a")
+	}
+	if write.SpanContext.TraceID() != traceID || write.Parent.SpanID() != root.SpanContext().SpanID() {
+		t.Errorf("write span is not a child of the client span")
+	}
+	replicated, ok := find("replicate", "b")
+	if !ok {
+		t.Fatalf("no span of the replication to b")
+	}
+	if replicated.SpanContext.TraceID() != traceID || replicated.Parent.SpanID() != write.SpanContext.SpanID() {
+		t.Errorf("replication to b is not a child of the write on a")
+	}
+	if len(replicated.Links) != 1 {
+		t.Fatalf("replication to b has %d links, wanted one to its gossip", len(replicated.Links))
+	}
+	gossip := replicated.Links[0].SpanContext.TraceID()
+	if gossip == traceID {
+		t.Errorf("replication to b is linked to the write's trace, wanted its gossip")
+	}
+	var gossipSpans int
+	for _, span := range spans {
+		if span.SpanContext.TraceID() == gossip {
+			gossipSpans++
+		}
+	}
+	if gossipSpans < 2 {
+		t.Errorf("gossip trace has %d spans, wanted both replicas", gossipSpans)
+	}
+
+	// The trace of the write is kept in its history on both replicas.
+	for _, node := range []string{"a", "b"} {
+		var history server.History
+		if code := impl.request(t, http.MethodGet, node, "/history", server.KV{Key: "k"}, &history); code != http.StatusOK {
+			t.Fatalf("history on %s failed with %d", node, code)
+		}
+		if len(history.Versions) != 1 {
+			t.Fatalf("history on %s has %d versions", node, len(history.Versions))
+		}
+		want := fmt.Sprintf("00-%s-%s-01", traceID, write.SpanContext.SpanID())
+		if got := history.Versions[0].Trace; got != want {
+			t.Errorf("trace of k on %s = %q, wanted %q", node, got, want)
+		}
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, key, value string) bool {
+	for _, kv := range attrs {
+		if string(kv.Key) == key && kv.Value.AsString() == value {
+			return true
+		}
+	}
+	return false
+}
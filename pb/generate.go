@@ -0,0 +1,5 @@
+// Package pb holds the protobuf schema of okayv and the code generated from
+// it. Regenerate it with go generate after editing okayv.proto.
+package pb
+
+//go:generate buf generate
@@ -0,0 +1,346 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: okayv.proto
+
+// Package okayv.v1 is the protobuf encoding of the okayv server's types and
+// its gRPC service. It mirrors the JSON API: see the server package for the
+// meaning of each field.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	OkayV_Read_FullMethodName            = "/okayv.v1.OkayV/Read"
+	OkayV_Write_FullMethodName           = "/okayv.v1.OkayV/Write"
+	OkayV_Delete_FullMethodName          = "/okayv.v1.OkayV/Delete"
+	OkayV_Gossip_FullMethodName          = "/okayv.v1.OkayV/Gossip"
+	OkayV_ViewChange_FullMethodName      = "/okayv.v1.OkayV/ViewChange"
+	OkayV_PutNamespace_FullMethodName    = "/okayv.v1.OkayV/PutNamespace"
+	OkayV_DeleteNamespace_FullMethodName = "/okayv.v1.OkayV/DeleteNamespace"
+)
+
+// OkayVClient is the client API for OkayV service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OkayVClient interface {
+	// Client operations.
+	Read(ctx context.Context, in *KV, opts ...grpc.CallOption) (*KV, error)
+	Write(ctx context.Context, in *KV, opts ...grpc.CallOption) (*KV, error)
+	Delete(ctx context.Context, in *KV, opts ...grpc.CallOption) (*KV, error)
+	// Replication and administration.
+	Gossip(ctx context.Context, in *Gossip, opts ...grpc.CallOption) (*GossipResponse, error)
+	ViewChange(ctx context.Context, in *ViewChange, opts ...grpc.CallOption) (*Empty, error)
+	PutNamespace(ctx context.Context, in *NamespaceChange, opts ...grpc.CallOption) (*Namespace, error)
+	DeleteNamespace(ctx context.Context, in *NamespaceChange, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type okayVClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOkayVClient(cc grpc.ClientConnInterface) OkayVClient {
+	return &okayVClient{cc}
+}
+
+func (c *okayVClient) Read(ctx context.Context, in *KV, opts ...grpc.CallOption) (*KV, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KV)
+	err := c.cc.Invoke(ctx, OkayV_Read_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *okayVClient) Write(ctx context.Context, in *KV, opts ...grpc.CallOption) (*KV, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KV)
+	err := c.cc.Invoke(ctx, OkayV_Write_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *okayVClient) Delete(ctx context.Context, in *KV, opts ...grpc.CallOption) (*KV, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KV)
+	err := c.cc.Invoke(ctx, OkayV_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *okayVClient) Gossip(ctx context.Context, in *Gossip, opts ...grpc.CallOption) (*GossipResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GossipResponse)
+	err := c.cc.Invoke(ctx, OkayV_Gossip_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *okayVClient) ViewChange(ctx context.Context, in *ViewChange, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, OkayV_ViewChange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *okayVClient) PutNamespace(ctx context.Context, in *NamespaceChange, opts ...grpc.CallOption) (*Namespace, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Namespace)
+	err := c.cc.Invoke(ctx, OkayV_PutNamespace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *okayVClient) DeleteNamespace(ctx context.Context, in *NamespaceChange, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, OkayV_DeleteNamespace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OkayVServer is the server API for OkayV service.
+// All implementations must embed UnimplementedOkayVServer
+// for forward compatibility
+type OkayVServer interface {
+	// Client operations.
+	Read(context.Context, *KV) (*KV, error)
+	Write(context.Context, *KV) (*KV, error)
+	Delete(context.Context, *KV) (*KV, error)
+	// Replication and administration.
+	Gossip(context.Context, *Gossip) (*GossipResponse, error)
+	ViewChange(context.Context, *ViewChange) (*Empty, error)
+	PutNamespace(context.Context, *NamespaceChange) (*Namespace, error)
+	DeleteNamespace(context.Context, *NamespaceChange) (*Empty, error)
+	mustEmbedUnimplementedOkayVServer()
+}
+
+// UnimplementedOkayVServer must be embedded to have forward compatible implementations.
+type UnimplementedOkayVServer struct {
+}
+
+func (UnimplementedOkayVServer) Read(context.Context, *KV) (*KV, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Read not implemented")
+}
+func (UnimplementedOkayVServer) Write(context.Context, *KV) (*KV, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Write not implemented")
+}
+func (UnimplementedOkayVServer) Delete(context.Context, *KV) (*KV, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedOkayVServer) Gossip(context.Context, *Gossip) (*GossipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Gossip not implemented")
+}
+func (UnimplementedOkayVServer) ViewChange(context.Context, *ViewChange) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ViewChange not implemented")
+}
+func (UnimplementedOkayVServer) PutNamespace(context.Context, *NamespaceChange) (*Namespace, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutNamespace not implemented")
+}
+func (UnimplementedOkayVServer) DeleteNamespace(context.Context, *NamespaceChange) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteNamespace not implemented")
+}
+func (UnimplementedOkayVServer) mustEmbedUnimplementedOkayVServer() {}
+
+// UnsafeOkayVServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OkayVServer will
+// result in compilation errors.
+type UnsafeOkayVServer interface {
+	mustEmbedUnimplementedOkayVServer()
+}
+
+func RegisterOkayVServer(s grpc.ServiceRegistrar, srv OkayVServer) {
+	s.RegisterService(&OkayV_ServiceDesc, srv)
+}
+
+func _OkayV_Read_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KV)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OkayVServer).Read(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OkayV_Read_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OkayVServer).Read(ctx, req.(*KV))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OkayV_Write_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KV)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OkayVServer).Write(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OkayV_Write_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OkayVServer).Write(ctx, req.(*KV))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OkayV_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KV)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OkayVServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OkayV_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OkayVServer).Delete(ctx, req.(*KV))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OkayV_Gossip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Gossip)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OkayVServer).Gossip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OkayV_Gossip_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OkayVServer).Gossip(ctx, req.(*Gossip))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OkayV_ViewChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ViewChange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OkayVServer).ViewChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OkayV_ViewChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OkayVServer).ViewChange(ctx, req.(*ViewChange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OkayV_PutNamespace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NamespaceChange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OkayVServer).PutNamespace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OkayV_PutNamespace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OkayVServer).PutNamespace(ctx, req.(*NamespaceChange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OkayV_DeleteNamespace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NamespaceChange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OkayVServer).DeleteNamespace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OkayV_DeleteNamespace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OkayVServer).DeleteNamespace(ctx, req.(*NamespaceChange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OkayV_ServiceDesc is the grpc.ServiceDesc for OkayV service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OkayV_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "okayv.v1.OkayV",
+	HandlerType: (*OkayVServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Read",
+			Handler:    _OkayV_Read_Handler,
+		},
+		{
+			MethodName: "Write",
+			Handler:    _OkayV_Write_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _OkayV_Delete_Handler,
+		},
+		{
+			MethodName: "Gossip",
+			Handler:    _OkayV_Gossip_Handler,
+		},
+		{
+			MethodName: "ViewChange",
+			Handler:    _OkayV_ViewChange_Handler,
+		},
+		{
+			MethodName: "PutNamespace",
+			Handler:    _OkayV_PutNamespace_Handler,
+		},
+		{
+			MethodName: "DeleteNamespace",
+			Handler:    _OkayV_DeleteNamespace_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "okayv.proto",
+}
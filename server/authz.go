@@ -0,0 +1,257 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spencer-p/okayv/auth"
+	"github.com/spencer-p/okayv/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxAuthBody is the most of a request body read to authenticate it.
+const maxAuthBody = 64 << 20
+
+// authorized wraps JSONHandler to authenticate requests and to authorize op
+// on the keys of the namespace that scope returns. An empty op only
+// authenticates, and a nil scope authorizes op alone.
+func authorized[In any, Out any](s *Server, op auth.Op, scope func(In) (string, []string), h func(In) (Out, error)) http.HandlerFunc {
+	return authorizedCtx(s, op, scope, func(_ context.Context, in In) (Out, error) {
+		return h(in)
+	})
+}
+
+// authorizedCtx is authorized for handlers that need the request context.
+func authorizedCtx[In any, Out any](s *Server, op auth.Op, scope func(In) (string, []string), h func(context.Context, In) (Out, error)) http.HandlerFunc {
+	return authorizedAs(s, op, scope, func(ctx context.Context, _ string, in In) (Out, error) {
+		return h(ctx, in)
+	})
+}
+
+// authorizedAs is authorized for handlers that need the request context and
+// the principal.
+func authorizedAs[In any, Out any](s *Server, op auth.Op, scope func(In) (string, []string), h func(context.Context, string, In) (Out, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, err := s.authenticate(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		JSONHandlerContext(func(ctx context.Context, in In) (Out, error) {
+			var namespace string
+			var keys []string
+			if scope != nil {
+				namespace, keys = scope(in)
+			}
+			if err := s.authorize(principal, op, namespace, keys); err != nil {
+				var out Out
+				return out, err
+			}
+			return h(ctx, principal, in)
+		})(w, r)
+	}
+}
+
+// guard is authorized for handlers that read the request themselves.
+func (s *Server) guard(scope func(*http.Request) (auth.Op, string, []string), h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, err := s.authenticate(r)
+		if err == nil {
+			op, namespace, keys := scope(r)
+			err = s.authorize(principal, op, namespace, keys)
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func keyScope(in KV) (string, []string)        { return in.Namespace, []string{in.Key} }
+func keysScope(in ReadMany) (string, []string) { return in.Namespace, in.Keys }
+func crdtScope(in CRDTOp) (string, []string)   { return in.Namespace, []string{in.Key} }
+func queryScope(in Query) (string, []string)   { return in.Namespace, []string{""} }
+
+// scanScope is the prefix, so that rules on longer prefixes do not allow it.
+func scanScope(in Scan) (string, []string) { return in.Namespace, []string{in.Prefix} }
+
+func adminScope(*http.Request) (auth.Op, string, []string) {
+	return auth.OpAdmin, "", nil
+}
+
+// authnScope only authenticates.
+func authnScope(*http.Request) (auth.Op, string, []string) {
+	return "", "", nil
+}
+
+// methodOp is a read for GET and HEAD and a write otherwise.
+func methodOp(r *http.Request) auth.Op {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return auth.OpRead
+	}
+	return auth.OpWrite
+}
+
+func rawScope(r *http.Request) (auth.Op, string, []string) {
+	q := r.URL.Query()
+	return methodOp(r), q.Get("namespace"), []string{q.Get("key")}
+}
+
+func restScope(r *http.Request) (auth.Op, string, []string) {
+	// Keys that do not decode are rejected by the handler.
+	key, _ := kvKey(r)
+	return methodOp(r), r.URL.Query().Get("namespace"), []string{key}
+}
+
+// authenticate returns the principal that made r. Without an Authenticator
+// every request is anonymous.
+func (s *Server) authenticate(r *http.Request) (string, error) {
+	if s.Authenticator == nil {
+		return "", nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAuthBody))
+	if err != nil {
+		return "", newerr(http.StatusBadRequest, err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	principal, err := s.Authenticator.Authenticate(r, body)
+	if err != nil {
+		return "", newerr(http.StatusUnauthorized, err)
+	}
+	return principal, nil
+}
+
+// authorize checks that principal may do op on every key. Without an ACL
+// everything is allowed.
+func (s *Server) authorize(principal string, op auth.Op, namespace string, keys []string) error {
+	if s.ACL == nil || op == "" {
+		return nil
+	}
+	if op == auth.OpAdmin {
+		if !s.ACL.Admin(principal) {
+			return newerr(http.StatusForbidden, fmt.Errorf("%q is not an admin", principal))
+		}
+		return nil
+	}
+	for _, key := range keys {
+		if !s.ACL.Allowed(principal, op, namespace, key) {
+			return newerr(http.StatusForbidden, fmt.Errorf("%q may not %s %q", principal, op, key))
+		}
+	}
+	return nil
+}
+
+// signPeer signs a request to a peer with the PeerSigner, if there is one.
+func (s *Server) signPeer(r *http.Request, body []byte) error {
+	if s.PeerSigner == nil {
+		return nil
+	}
+	return s.PeerSigner.Sign(r, body)
+}
+
+// writeError writes err as a JSON error with its status code.
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if withcode, ok := err.(HttpError); ok {
+		code = withcode.Code()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// RPCs carry the credentials of a request in metadata, and are signed over
+// their deterministic protobuf encoding.
+var rpcAuthHeaders = []string{"Authorization", auth.DateHeader, auth.NonceHeader}
+
+func rpcRequest(method string, msg any) (*http.Request, []byte, error) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: %T is not a protobuf message", method, msg)
+	}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Path: method},
+		Header: make(http.Header),
+	}
+	return r, body, nil
+}
+
+// rpcScope returns what an RPC does to which keys.
+func rpcScope(method string, req any) (auth.Op, string, []string) {
+	kv, _ := req.(*pb.KV)
+	switch method {
+	case pb.OkayV_Read_FullMethodName:
+		return auth.OpRead, kv.GetNamespace(), []string{kv.GetKey()}
+	case pb.OkayV_Write_FullMethodName, pb.OkayV_Delete_FullMethodName:
+		return auth.OpWrite, kv.GetNamespace(), []string{kv.GetKey()}
+	}
+	return auth.OpAdmin, "", nil
+}
+
+// authInterceptor authenticates and authorizes RPCs as their HTTP
+// equivalents are.
+func (s *Server) authInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if s.Authenticator == nil && s.ACL == nil {
+		return handler(ctx, req)
+	}
+	var principal string
+	if s.Authenticator != nil {
+		r, body, err := rpcRequest(info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, name := range rpcAuthHeaders {
+			if v := md.Get(name); len(v) > 0 {
+				r.Header.Set(name, v[0])
+			}
+		}
+		if principal, err = s.Authenticator.Authenticate(r, body); err != nil {
+			return nil, grpcError(newerr(http.StatusUnauthorized, err))
+		}
+	}
+	op, namespace, keys := rpcScope(info.FullMethod, req)
+	if err := s.authorize(principal, op, namespace, keys); err != nil {
+		return nil, grpcError(err)
+	}
+	return handler(context.WithValue(ctx, principalKey{}, principal), req)
+}
+
+type principalKey struct{}
+
+// rpcPrincipal returns the principal authInterceptor found for an RPC.
+func rpcPrincipal(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+// signInterceptor signs RPCs to peers with the PeerSigner.
+func (s *Server) signInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	r, body, err := rpcRequest(method, req)
+	if err != nil {
+		return err
+	}
+	if err := s.PeerSigner.Sign(r, body); err != nil {
+		return err
+	}
+	for _, name := range rpcAuthHeaders {
+		if v := r.Header.Get(name); v != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(name), v)
+		}
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
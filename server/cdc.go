@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ChangeKind describes what happened to the event log.
+type ChangeKind string
+
+const (
+	// ChangeWrite is a column created by a client write on this replica.
+	ChangeWrite ChangeKind = "write"
+	// ChangeReplicate is a column received from a peer and appended.
+	ChangeReplicate ChangeKind = "replicate"
+	// ChangeAck is an update to the replication set of an existing column.
+	ChangeAck ChangeKind = "ack"
+	// ChangeDrop is a remote column that lost a timestamp tie-break and was
+	// never appended to the log.
+	ChangeDrop ChangeKind = "drop"
+)
+
+const defaultChangeLimit = 1000
+
+// DefaultChangeRetention is the number of changes kept when
+// Opts.ChangeRetention is zero.
+const DefaultChangeRetention = 1 << 16
+
+// ChangeSchema is the version of the Change record. Fields are only ever
+// added, so consumers of an older version can read newer records.
+//
+//   - 1: the first version. Value is the value as a string.
+//   - 2: adds Schema, ValueBytes, ContentType, Namespace, Deleted and Trace.
+const ChangeSchema = 2
+
+// Change is one change-data-capture record. Offsets are dense and local to the
+// replica that produced them.
+type Change struct {
+	Schema  int        `json:"schema"`
+	Offset  int        `json:"offset"`
+	Replica string     `json:"replica"`
+	Kind    ChangeKind `json:"kind"`
+	Source  string     `json:"source,omitempty"`
+	Index   int        `json:"index"`
+	ID      string     `json:"id"`
+	Key     string     `json:"key"`
+	// Value is the value as a string, in which invalid UTF-8 is replaced.
+	// ValueBytes is the exact value.
+	Value       string      `json:"value"`
+	ValueBytes  []byte      `json:"value-bytes"`
+	ContentType string      `json:"content-type,omitempty"`
+	Namespace   string      `json:"namespace,omitempty"`
+	Deleted     bool        `json:"deleted,omitempty"`
+	Context     VectorClock `json:"causal-context"`
+	Replicated  []string    `json:"replicated"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Origin      string      `json:"origin"`
+	Trace       string      `json:"trace,omitempty"`
+}
+
+// ErrTruncated is returned for offsets before the first change retained.
+var ErrTruncated = errors.New("changes were truncated")
+
+// logChange records a change to the event log. idx is the index of the column
+// in events, or -1 if the column was not appended.
+// logChange assumes the write lock is held.
+func (s *Server) logChange(kind ChangeKind, src string, idx int, col Column) {
+	replicated := make([]string, 0, len(col.Clock.Replicated))
+	for name := range col.Clock.Replicated {
+		replicated = append(replicated, name)
+	}
+	sort.Strings(replicated)
+
+	s.changes = append(s.changes, Change{
+		Schema:      ChangeSchema,
+		Offset:      s.firstChange + len(s.changes),
+		Replica:     s.Name,
+		Kind:        kind,
+		Source:      src,
+		Index:       idx,
+		ID:          col.Clock.ID.String(),
+		Namespace:   col.Namespace,
+		Key:         col.Key,
+		Value:       string(col.Value),
+		ValueBytes:  col.Value,
+		ContentType: col.ContentType,
+		Deleted:     col.Deleted,
+		Context:     col.Clock.Context.Clone(),
+		Replicated:  replicated,
+		Timestamp:   col.Timestamp,
+		Origin:      col.Origin,
+		Trace:       col.Trace,
+	})
+	if drop := len(s.changes) - s.ChangeRetention; drop > 0 {
+		// Reslice rather than copy the retained changes on every write. Once
+		// the slice runs out of capacity, append moves them to a new array,
+		// so a write costs amortized O(1). The dropped changes are cleared so
+		// that what they reference can be collected until then.
+		clear(s.changes[:drop])
+		s.changes = s.changes[drop:]
+		s.firstChange += drop
+	}
+}
+
+// changesFrom returns up to limit changes starting at offset, and the offset
+// of the first change retained. Offsets before it return ErrTruncated.
+func (s *Server) changesFrom(offset, limit int) ([]Change, int, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if offset < s.firstChange {
+		return nil, s.firstChange, fmt.Errorf("offset %d is before the first change %d: %w", offset, s.firstChange, ErrTruncated)
+	}
+	start := offset - s.firstChange
+	if start >= len(s.changes) {
+		return nil, s.firstChange, nil
+	}
+	end := min(start+limit, len(s.changes))
+	return append([]Change(nil), s.changes[start:end]...), s.firstChange, nil
+}
+
+// FirstOffsetHeader carries the offset of the first change retained.
+const FirstOffsetHeader = "X-First-Offset"
+
+// serveChanges writes changes as newline-delimited JSON. Truncated offsets are
+// gone.
+func (s *Server) serveChanges(w http.ResponseWriter, r *http.Request) {
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := intParam(r, "limit", defaultChangeLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changes, first, err := s.changesFrom(offset, limit)
+	w.Header().Set(FirstOffsetHeader, strconv.Itoa(first))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	for _, change := range changes {
+		if err := enc.Encode(&change); err != nil {
+			return
+		}
+	}
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, raw)
+	}
+	return v, nil
+}
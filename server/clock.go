@@ -148,3 +148,15 @@ func zipkeys(a, b VectorClock) map[string]struct{} {
 	}
 	return result
 }
+
+// Minus returns the events us has counted beyond them, by node. Nodes where
+// us is not ahead are absent.
+func (us VectorClock) Minus(them VectorClock) VectorClock {
+	diff := make(VectorClock)
+	for key, ctr := range us {
+		if ctr > them[key] {
+			diff[key] = ctr - them[key]
+		}
+	}
+	return diff
+}
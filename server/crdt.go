@@ -0,0 +1,314 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CRDTType string
+
+const (
+	// GCounter is a counter that can only be incremented.
+	GCounter CRDTType = "g-counter"
+	// PNCounter is a counter that can be incremented and decremented.
+	PNCounter CRDTType = "pn-counter"
+	// ORSet is a set where an add wins over a concurrent remove.
+	ORSet CRDTType = "or-set"
+	// LWWRegister is a value where the newest write wins.
+	LWWRegister CRDTType = "lww-register"
+)
+
+// CRDT is the state of a typed key. Concurrent states of the same type are
+// merged instead of going through the timestamp tie-break. Only the fields
+// used by Type are set.
+type CRDT struct {
+	Type CRDTType `json:"type"`
+	// P and N hold the increments and decrements of counters by replica.
+	P map[string]uint64 `json:"p,omitempty"`
+	N map[string]uint64 `json:"n,omitempty"`
+	// Adds holds the unique tags of the adds of each set element. Removed
+	// holds the tags that were observed by a remove.
+	Adds    map[string]map[string]nothing `json:"adds,omitempty"`
+	Removed map[string]nothing            `json:"removed,omitempty"`
+	// Register is the value of a register, written at Time by Replica.
+	Register    []byte    `json:"register,omitempty"`
+	ContentType string    `json:"content-type,omitempty"`
+	Time        time.Time `json:"time,omitempty"`
+	Replica     string    `json:"replica,omitempty"`
+}
+
+func newCRDT(t CRDTType) (*CRDT, error) {
+	c := &CRDT{Type: t}
+	switch t {
+	case GCounter, PNCounter:
+		c.P = make(map[string]uint64)
+		c.N = make(map[string]uint64)
+	case ORSet:
+		c.Adds = make(map[string]map[string]nothing)
+		c.Removed = make(map[string]nothing)
+	case LWWRegister:
+	default:
+		return nil, fmt.Errorf("unknown type %q", t)
+	}
+	return c, nil
+}
+
+// Clone returns a deep copy of c. Empty maps, which are not encoded, are
+// allocated again.
+func (c *CRDT) Clone() *CRDT {
+	if c == nil {
+		return nil
+	}
+	out, err := newCRDT(c.Type)
+	if err != nil {
+		out = &CRDT{Type: c.Type}
+	}
+	out.Merge(c)
+	return out
+}
+
+// Merge combines other into c. Merging is commutative, associative and
+// idempotent, so replicas that merged the same states agree.
+func (c *CRDT) Merge(other *CRDT) {
+	for replica, n := range other.P {
+		c.P[replica] = max(c.P[replica], n)
+	}
+	for replica, n := range other.N {
+		c.N[replica] = max(c.N[replica], n)
+	}
+	for tag := range other.Removed {
+		c.Removed[tag] = nothing{}
+	}
+	for elem, tags := range other.Adds {
+		for tag := range tags {
+			if c.Adds[elem] == nil {
+				c.Adds[elem] = make(map[string]nothing)
+			}
+			c.Adds[elem][tag] = nothing{}
+		}
+	}
+	if c.Type == ORSet {
+		c.compact()
+	}
+	if other.newer(c) {
+		c.Register = other.Register
+		c.ContentType = other.ContentType
+		c.Time = other.Time
+		c.Replica = other.Replica
+	}
+}
+
+// newer reports whether the register write of c wins over that of other.
+// Replica names break ties between writes with the same time.
+func (c *CRDT) newer(other *CRDT) bool {
+	if !c.Time.Equal(other.Time) {
+		return c.Time.After(other.Time)
+	}
+	return c.Replica > other.Replica
+}
+
+// compact forgets the tags of adds that were removed.
+func (c *CRDT) compact() {
+	for elem, tags := range c.Adds {
+		for tag := range tags {
+			if _, ok := c.Removed[tag]; ok {
+				delete(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			delete(c.Adds, elem)
+		}
+	}
+}
+
+// Counter returns the value of a counter.
+func (c *CRDT) Counter() int64 {
+	var sum int64
+	for _, n := range c.P {
+		sum += int64(n)
+	}
+	for _, n := range c.N {
+		sum -= int64(n)
+	}
+	return sum
+}
+
+// Members returns the sorted elements of a set.
+func (c *CRDT) Members() []string {
+	members := make([]string, 0, len(c.Adds))
+	for elem := range c.Adds {
+		members = append(members, elem)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// render returns the value and content type that reads of the key return.
+// Counters and sets are encoded as JSON.
+func (c *CRDT) render() ([]byte, string) {
+	var v any
+	switch c.Type {
+	case GCounter, PNCounter:
+		v = c.Counter()
+	case ORSet:
+		v = c.Members()
+	default:
+		return c.Register, c.ContentType
+	}
+	buf, _ := json.Marshal(v)
+	return buf, "application/json"
+}
+
+// CRDTOp is an operation on a typed key. Type is only needed to create the
+// key; operations on an existing key of another type fail with 409.
+type CRDTOp struct {
+	Namespace string   `json:"namespace,omitempty"`
+	Key       string   `json:"key"`
+	Type      CRDTType `json:"type,omitempty"`
+	// Delta is added to a counter.
+	Delta int64 `json:"delta,omitempty"`
+	// Add and Remove list elements to add to and remove from a set.
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+	// Value and ContentType are written to a register.
+	Value       []byte      `json:"value,omitempty"`
+	ContentType string      `json:"content-type,omitempty"`
+	Context     VectorClock `json:"causal-context,omitempty"`
+}
+
+func (s *Server) counter(ctx context.Context, in CRDTOp) (KV, error) {
+	return s.applyOp(ctx, in, []CRDTType{PNCounter, GCounter}, func(c *CRDT) error {
+		if in.Delta >= 0 {
+			c.P[s.Name] += uint64(in.Delta)
+			return nil
+		}
+		if c.Type == GCounter {
+			return newerr(http.StatusBadRequest, fmt.Errorf("cannot decrement a g-counter"))
+		}
+		c.N[s.Name] += uint64(-in.Delta)
+		return nil
+	})
+}
+
+func (s *Server) set(ctx context.Context, in CRDTOp) (KV, error) {
+	return s.applyOp(ctx, in, []CRDTType{ORSet}, func(c *CRDT) error {
+		// A remove only covers the adds it has observed, so a concurrent add
+		// on another replica survives it.
+		for _, elem := range in.Remove {
+			for tag := range c.Adds[elem] {
+				c.Removed[tag] = nothing{}
+			}
+		}
+		for _, elem := range in.Add {
+			if c.Adds[elem] == nil {
+				c.Adds[elem] = make(map[string]nothing)
+			}
+			c.Adds[elem][uuid.NewString()] = nothing{}
+		}
+		c.compact()
+		return nil
+	})
+}
+
+func (s *Server) register(ctx context.Context, in CRDTOp) (KV, error) {
+	s.lock.RLock()
+	ns, err := s.namespace(in.Namespace)
+	s.lock.RUnlock()
+	if err != nil {
+		return KV{}, err
+	}
+	if limit := s.valueLimit(ns); len(in.Value) > limit {
+		return KV{}, newerr(http.StatusRequestEntityTooLarge, fmt.Errorf("value of %d bytes exceeds limit of %d", len(in.Value), limit))
+	}
+	return s.applyOp(ctx, in, []CRDTType{LWWRegister}, func(c *CRDT) error {
+		c.Register = in.Value
+		c.ContentType = in.ContentType
+		c.Time = time.Now()
+		c.Replica = s.Name
+		return nil
+	})
+}
+
+// applyOp applies op to a copy of the current state of a typed key and
+// records the result as a new write, replicated like one. The key must be
+// one of types. An op without a type takes the type of the existing key, and
+// creates one of types[0].
+func (s *Server) applyOp(ctx context.Context, in CRDTOp, types []CRDTType, op func(*CRDT) error) (KV, error) {
+	if in.Type != "" && !slices.Contains(types, in.Type) {
+		return KV{}, newerr(http.StatusBadRequest, fmt.Errorf("%q is not a %s", in.Type, types[0]))
+	}
+	if err := s.acceptingWrites(); err != nil {
+		return KV{}, err
+	}
+	out, rf, err := s.mutate(ctx, in, types, op)
+	if err != nil || rf <= 1 {
+		return out, err
+	}
+	out.Replicas = s.replicate(ctx, out, rf)
+	out.Pending = out.Replicas < rf
+	return out, nil
+}
+
+// mutate is the local part of applyOp. It returns the replication factor of
+// the key's namespace.
+func (s *Server) mutate(ctx context.Context, in CRDTOp, types []CRDTType, op func(*CRDT) error) (KV, int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Info("CRDT op", "key", in.Key, "type", in.Type, "ctx", in.Context)
+
+	if err := s.behind(in.Context); err != nil {
+		return KV{}, 0, err
+	}
+	ns, err := s.namespace(in.Namespace)
+	if err != nil {
+		return KV{}, 0, err
+	}
+
+	k := nskey{in.Namespace, in.Key}
+	var state *CRDT
+	if existing, ok := s.lookup(k); ok {
+		if existing.CRDT == nil || !slices.Contains(types, existing.CRDT.Type) || (in.Type != "" && existing.CRDT.Type != in.Type) {
+			want := in.Type
+			if want == "" {
+				want = types[0]
+			}
+			return KV{}, 0, newerr(http.StatusConflict, fmt.Errorf("%s is not a %s", in.Key, want))
+		}
+		state = existing.CRDT.Clone()
+	} else {
+		typ := in.Type
+		if typ == "" {
+			typ = types[0]
+		}
+		if state, err = newCRDT(typ); err != nil {
+			return KV{}, 0, newerr(http.StatusBadRequest, err)
+		}
+	}
+	if err := op(state); err != nil {
+		return KV{}, 0, err
+	}
+
+	value, contentType := state.render()
+	col := s.appendLocal(Column{
+		Namespace:   in.Namespace,
+		Key:         in.Key,
+		Value:       value,
+		ContentType: contentType,
+		CRDT:        state,
+		Trace:       traceparent(ctx),
+	}, in.Context, ns.TTL)
+	return KV{
+		Namespace:   col.Namespace,
+		Key:         col.Key,
+		Value:       col.Value,
+		ContentType: col.ContentType,
+		Context:     col.Clock.Context,
+	}, ns.ReplicationFactor, nil
+}
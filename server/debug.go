@@ -0,0 +1,113 @@
+package server
+
+import (
+	"sort"
+	"time"
+)
+
+// DebugQuery selects the state dumped by /debug/state. Without a key, the
+// whole state is dumped; with one, only the parts about that key.
+type DebugQuery struct {
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+// DebugState is a dump of the internal state of a server. Lists are ordered
+// and maps are encoded with sorted keys, so equal states dump equally.
+type DebugState struct {
+	Name   string         `json:"name"`
+	MaxCC  VectorClock    `json:"maxcc"`
+	Events []DebugEvent   `json:"events"`
+	Latest []DebugLatest  `json:"latest"`
+	Acked  map[string]int `json:"acked"`
+	ByID   map[string]int `json:"byid"`
+}
+
+// DebugEvent is a column of the event log with its position.
+type DebugEvent struct {
+	Index       int         `json:"index"`
+	ID          string      `json:"id"`
+	Namespace   string      `json:"namespace,omitempty"`
+	Key         string      `json:"key"`
+	Value       []byte      `json:"value"`
+	ContentType string      `json:"content-type,omitempty"`
+	Deleted     bool        `json:"deleted,omitempty"`
+	Context     VectorClock `json:"causal-context"`
+	Replicated  []string    `json:"replicated"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Origin      string      `json:"origin"`
+}
+
+// DebugLatest is the index of the current version of a key.
+type DebugLatest struct {
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key"`
+	Index     int    `json:"index"`
+}
+
+// DebugState dumps the state of the server selected by q.
+func (s *Server) DebugState(q DebugQuery) DebugState {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	perKey := q.Key != ""
+	match := func(col Column) bool {
+		return !perKey || col.nskey() == nskey{q.Namespace, q.Key}
+	}
+
+	state := DebugState{
+		Name:   s.Name,
+		MaxCC:  s.maxcc.Clone(),
+		Events: []DebugEvent{},
+		Latest: []DebugLatest{},
+		Acked:  make(map[string]int, len(s.acked)),
+		ByID:   make(map[string]int),
+	}
+	for i, col := range s.events {
+		if !match(col) {
+			continue
+		}
+		replicated := make([]string, 0, len(col.Clock.Replicated))
+		for name := range col.Clock.Replicated {
+			replicated = append(replicated, name)
+		}
+		sort.Strings(replicated)
+		state.Events = append(state.Events, DebugEvent{
+			Index:       i,
+			ID:          col.Clock.ID.String(),
+			Namespace:   col.Namespace,
+			Key:         col.Key,
+			Value:       col.Value,
+			ContentType: col.ContentType,
+			Deleted:     col.Deleted,
+			Context:     col.Clock.Context.Clone(),
+			Replicated:  replicated,
+			Timestamp:   col.Timestamp,
+			Origin:      col.Origin,
+		})
+	}
+	for k, idx := range s.latest {
+		if match(s.events[idx]) {
+			state.Latest = append(state.Latest, DebugLatest{Namespace: k.Namespace, Key: k.Key, Index: idx})
+		}
+	}
+	sort.Slice(state.Latest, func(i, j int) bool {
+		a, b := state.Latest[i], state.Latest[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Key < b.Key
+	})
+	for id, idx := range s.byid {
+		if match(s.events[idx]) {
+			state.ByID[id] = idx
+		}
+	}
+	for peer, idx := range s.acked {
+		state.Acked[peer] = idx
+	}
+	return state
+}
+
+func (s *Server) debugState(q DebugQuery) (DebugState, error) {
+	return s.DebugState(q), nil
+}
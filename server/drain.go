@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Drain stops the server from accepting writes and pushes its unreplicated
+// columns to its peers until each has acked them all. It returns an error if
+// any remain when ctx is done. The server keeps its history only in memory,
+// so columns that were not drained are lost when it exits.
+func (s *Server) Drain(ctx context.Context) error {
+	s.draining.Store(true)
+	s.Info("Draining")
+	retry := s.gossipFreq()
+	if retry <= 0 {
+		retry = 100 * time.Millisecond
+	}
+	for {
+		for _, peer := range s.peerList() {
+			if err := s.gossipMode(ctx, peer, GossipPush); err != nil {
+				s.Warn("Failed to drain", "dst", peer, "err", err)
+			}
+		}
+		pending := 0
+		for _, lag := range s.ReplicationLag() {
+			pending += lag.Unreplicated
+		}
+		if pending == 0 {
+			s.Info("Drained")
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d events not replicated: %w", pending, ctx.Err())
+		case <-time.After(retry):
+		}
+	}
+}
+
+// acceptingWrites returns an error once the server is draining.
+func (s *Server) acceptingWrites() error {
+	if s.draining.Load() {
+		return newerr(http.StatusServiceUnavailable, fmt.Errorf("server is shutting down"))
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spencer-p/okayv/pb"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/stats"
+)
+
+// Compressions of gossip. Over HTTP they are negotiated with the
+// Accept-Encoding and Content-Encoding headers, and over gRPC with its own
+// encoding headers.
+const (
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+func init() {
+	encoding.RegisterCompressor(zstdCompressor{})
+}
+
+// encodeContexts returns copies of cols whose contexts only hold the entries
+// that differ from the context of the previous column. An entry that was
+// removed is encoded as zero.
+func encodeContexts(cols []Column) []Column {
+	if len(cols) == 0 {
+		return cols
+	}
+	out := make([]Column, len(cols))
+	var prev VectorClock
+	for i, col := range cols {
+		var delta VectorClock
+		for node, ctr := range col.Clock.Context {
+			if prev[node] != ctr {
+				if delta == nil {
+					delta = make(VectorClock)
+				}
+				delta[node] = ctr
+			}
+		}
+		for node := range prev {
+			if _, ok := col.Clock.Context[node]; !ok {
+				if delta == nil {
+					delta = make(VectorClock)
+				}
+				delta[node] = 0
+			}
+		}
+		out[i] = col
+		out[i].Clock.Context = delta
+		prev = col.Clock.Context
+	}
+	return out
+}
+
+// decodeContexts restores the full contexts of delta encoded cols.
+func decodeContexts(cols []Column) []Column {
+	prev := VectorClock{}
+	for i := range cols {
+		ctx := prev.Clone()
+		for node, ctr := range cols[i].Clock.Context {
+			if ctr == 0 {
+				delete(ctx, node)
+			} else {
+				ctx[node] = ctr
+			}
+		}
+		cols[i].Clock.Context = ctx
+		prev = ctx
+	}
+	return cols
+}
+
+// GossipStats counts the bytes of gossip a server sent and received. Bytes
+// are counted before compression and wire bytes after.
+type GossipStats struct {
+	SentBytes         int64 `json:"sent-bytes"`
+	SentWireBytes     int64 `json:"sent-wire-bytes"`
+	ReceivedBytes     int64 `json:"received-bytes"`
+	ReceivedWireBytes int64 `json:"received-wire-bytes"`
+}
+
+type gossipCounters struct {
+	sent, sentWire, received, receivedWire atomic.Int64
+}
+
+func (c *gossipCounters) countSent(n, wire int) {
+	c.sent.Add(int64(n))
+	c.sentWire.Add(int64(wire))
+}
+
+func (c *gossipCounters) countReceived(n, wire int) {
+	c.received.Add(int64(n))
+	c.receivedWire.Add(int64(wire))
+}
+
+// GossipStats returns the byte counts of gossip so far.
+func (s *Server) GossipStats() GossipStats {
+	return GossipStats{
+		SentBytes:         s.stats.sent.Load(),
+		SentWireBytes:     s.stats.sentWire.Load(),
+		ReceivedBytes:     s.stats.received.Load(),
+		ReceivedWireBytes: s.stats.receivedWire.Load(),
+	}
+}
+
+func (s *Server) gossipStats(struct{}) (GossipStats, error) {
+	return s.GossipStats(), nil
+}
+
+// gossipHTTP sends gossip as JSON. The body is compressed once the peer has
+// shown it understands the compression by using it in a reply.
+func (s *Server) gossipHTTP(ctx context.Context, dst *url.URL, req Gossip) (GossipResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return GossipResponse{}, err
+	}
+	n := len(body)
+	enc := s.peerEncoding(dst.Host)
+	if enc != "" {
+		if body, err = compress(enc, body); err != nil {
+			return GossipResponse{}, err
+		}
+	}
+
+	httpreq, err := http.NewRequestWithContext(ctx, http.MethodPut, dst.String()+"/gossip", bytes.NewReader(body))
+	if err != nil {
+		return GossipResponse{}, err
+	}
+	httpreq.Header.Set("User-Agent", s.Name)
+	httpreq.Header.Set("Content-Type", "application/json")
+	injectTrace(ctx, httpreq)
+	if enc != "" {
+		httpreq.Header.Set("Content-Encoding", enc)
+	}
+	if s.GossipCompression != "" {
+		httpreq.Header.Set("Accept-Encoding", s.GossipCompression)
+	}
+	if err := s.signPeer(httpreq, body); err != nil {
+		return GossipResponse{}, err
+	}
+	s.stats.countSent(n, len(body))
+	httpresp, err := s.Client.Do(httpreq)
+	if err != nil {
+		return GossipResponse{}, err
+	}
+	defer httpresp.Body.Close()
+
+	wire, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return GossipResponse{}, err
+	}
+	if httpresp.StatusCode != http.StatusOK {
+		return GossipResponse{}, fmt.Errorf("gossip to %s failed with code %d: %s", dst.Host, httpresp.StatusCode, wire)
+	}
+	body = wire
+	if enc := httpresp.Header.Get("Content-Encoding"); enc != "" {
+		if body, err = decompress(enc, wire); err != nil {
+			return GossipResponse{}, err
+		}
+		s.setPeerEncoding(dst.Host, enc)
+	}
+	s.stats.countReceived(len(body), len(wire))
+
+	var resp GossipResponse
+	err = json.Unmarshal(body, &resp)
+	return resp, err
+}
+
+func (s *Server) peerEncoding(host string) string {
+	s.connlock.Lock()
+	defer s.connlock.Unlock()
+	if enc := s.encodings[host]; enc == s.GossipCompression {
+		return enc
+	}
+	return ""
+}
+
+func (s *Server) setPeerEncoding(host, enc string) {
+	s.connlock.Lock()
+	defer s.connlock.Unlock()
+	s.encodings[host] = enc
+}
+
+// gossipEncoding decompresses gossip requests and compresses the replies
+// with the first compression the sender accepts.
+func (s *Server) gossipEncoding(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wire, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body := wire
+		if enc := r.Header.Get("Content-Encoding"); enc != "" {
+			if !supportedEncoding(enc) {
+				http.Error(w, fmt.Sprintf("unsupported encoding %q", enc), http.StatusUnsupportedMediaType)
+				return
+			}
+			if body, err = decompress(enc, wire); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		s.stats.countReceived(len(body), len(wire))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		buf := &bufferedResponse{header: w.Header(), code: http.StatusOK}
+		h(buf, r)
+		out := buf.body.Bytes()
+		n := len(out)
+		if enc := acceptedEncoding(r.Header.Get("Accept-Encoding")); enc != "" && buf.code == http.StatusOK {
+			if compressed, err := compress(enc, out); err == nil {
+				out = compressed
+				w.Header().Set("Content-Encoding", enc)
+			}
+		}
+		s.stats.countSent(n, len(out))
+		w.WriteHeader(buf.code)
+		_, _ = w.Write(out)
+	}
+}
+
+// bufferedResponse holds a response so that it can be compressed whole.
+type bufferedResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(code int)        { b.code = code }
+
+func supportedEncoding(enc string) bool {
+	return enc == CompressGzip || enc == CompressZstd
+}
+
+// acceptedEncoding returns the first supported compression in an
+// Accept-Encoding header.
+func acceptedEncoding(header string) string {
+	for _, enc := range strings.Split(header, ",") {
+		enc, _, _ = strings.Cut(enc, ";")
+		if enc = strings.TrimSpace(enc); supportedEncoding(enc) {
+			return enc
+		}
+	}
+	return ""
+}
+
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		enc, _ := zstd.NewWriter(nil)
+		return enc
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		dec, _ := zstd.NewReader(nil)
+		return dec
+	})
+)
+
+func compress(enc string, data []byte) ([]byte, error) {
+	switch enc {
+	case CompressGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressZstd:
+		return zstdEncoder().EncodeAll(data, nil), nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", enc)
+}
+
+func decompress(enc string, data []byte) ([]byte, error) {
+	switch enc {
+	case CompressGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case CompressZstd:
+		return zstdDecoder().DecodeAll(data, nil)
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", enc)
+}
+
+// zstdCompressor is the gRPC compressor for zstd. Messages are compressed
+// whole.
+type zstdCompressor struct{}
+
+func (zstdCompressor) Name() string { return CompressZstd }
+
+func (zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return &zstdWriter{w: w}, nil
+}
+
+func (zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	out, err := zstdDecoder().DecodeAll(data, nil)
+	return bytes.NewReader(out), err
+}
+
+type zstdWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (z *zstdWriter) Write(p []byte) (int, error) { return z.buf.Write(p) }
+
+func (z *zstdWriter) Close() error {
+	_, err := z.w.Write(zstdEncoder().EncodeAll(z.buf.Bytes(), nil))
+	return err
+}
+
+// gossipStatsHandler counts the bytes of gossip RPCs.
+type gossipStatsHandler struct {
+	s *Server
+}
+
+type rpcMethodKey struct{}
+
+func (h gossipStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, rpcMethodKey{}, info.FullMethodName)
+}
+
+func (h gossipStatsHandler) HandleRPC(ctx context.Context, st stats.RPCStats) {
+	if method, _ := ctx.Value(rpcMethodKey{}).(string); method != pb.OkayV_Gossip_FullMethodName {
+		return
+	}
+	switch p := st.(type) {
+	case *stats.InPayload:
+		h.s.stats.countReceived(p.Length, p.CompressedLength)
+	case *stats.OutPayload:
+		h.s.stats.countSent(p.Length, p.CompressedLength)
+	}
+}
+
+func (h gossipStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h gossipStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
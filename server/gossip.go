@@ -0,0 +1,91 @@
+package server
+
+import (
+	"math/rand"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+type GossipMode string
+
+const (
+	// GossipPushPull sends unreplicated columns to a peer and takes the
+	// peer's unreplicated columns back in the reply. It is the default.
+	GossipPushPull GossipMode = "push-pull"
+	// GossipPush only sends unreplicated columns to a peer.
+	GossipPush GossipMode = "push"
+	// GossipPull only asks a peer for its unreplicated columns.
+	GossipPull GossipMode = "pull"
+)
+
+type PeerPolicy string
+
+const (
+	// PeerRandom gossips with random peers. It is the default.
+	PeerRandom PeerPolicy = "random"
+	// PeerRoundRobin gossips with each peer in turn.
+	PeerRoundRobin PeerPolicy = "round-robin"
+	// PeerMostUnacked gossips with the peers that have acknowledged the
+	// fewest events.
+	PeerMostUnacked PeerPolicy = "most-unacked"
+)
+
+// Gossip replicates with GossipFanout peers chosen by the peer policy. The
+// peers are gossiped with concurrently.
+func (s *Server) Gossip() {
+	var wg sync.WaitGroup
+	for _, peer := range s.choosePeers() {
+		wg.Add(1)
+		go func(peer *url.URL) {
+			defer wg.Done()
+			if err := s.gossipOnce(peer); err != nil {
+				s.Warn("Failed to gossip", "dst", peer, "err", err)
+			}
+		}(peer)
+	}
+	wg.Wait()
+}
+
+// choosePeers returns up to GossipFanout peers to gossip with.
+func (s *Server) choosePeers() []*url.URL {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n := min(s.GossipFanout, len(s.peers))
+	if n == 0 {
+		return nil
+	}
+	peers := make([]*url.URL, 0, n)
+	switch s.PeerPolicy {
+	case PeerRoundRobin:
+		for i := 0; i < n; i++ {
+			peers = append(peers, s.peers[(s.nextPeer+i)%len(s.peers)])
+		}
+		s.nextPeer = (s.nextPeer + n) % len(s.peers)
+	case PeerMostUnacked:
+		// Shuffle first so that ties are broken randomly.
+		unacked := make(map[*url.URL]int, len(s.peers))
+		for _, i := range rand.Perm(len(s.peers)) {
+			peer := s.peers[i]
+			unacked[peer] = len(s.events) - s.indexNotAcked(peer.Host)
+			peers = append(peers, peer)
+		}
+		sort.SliceStable(peers, func(i, j int) bool {
+			return unacked[peers[i]] > unacked[peers[j]]
+		})
+		peers = peers[:n]
+	default:
+		for _, i := range rand.Perm(len(s.peers))[:n] {
+			peers = append(peers, s.peers[i])
+		}
+	}
+	return peers
+}
+
+// peerList returns a copy of the current peers.
+func (s *Server) peerList() []*url.URL {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return append([]*url.URL(nil), s.peers...)
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/spencer-p/okayv/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCScheme selects the gRPC transport for a peer in a view change, as in
+// "grpc://host:port". Peers with any other scheme are sent JSON over HTTP.
+const GRPCScheme = "grpc"
+
+// RegisterGRPC serves the client and replication API on a gRPC server.
+func (s *Server) RegisterGRPC(g *grpc.Server) {
+	pb.RegisterOkayVServer(g, grpcService{s: s})
+}
+
+// GRPCServerOptions returns the options a gRPC server of the API needs to
+// count gossip bytes, to trace and to authorize calls.
+func (s *Server) GRPCServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.StatsHandler(gossipStatsHandler{s}),
+		grpc.ChainUnaryInterceptor(s.traceInterceptor, s.authInterceptor),
+	}
+}
+
+type grpcService struct {
+	pb.UnimplementedOkayVServer
+	s *Server
+}
+
+func (g grpcService) Read(_ context.Context, in *pb.KV) (*pb.KV, error) {
+	out, err := g.s.read(kvFromPB(in))
+	return kvToPB(out), grpcError(err)
+}
+
+func (g grpcService) Write(ctx context.Context, in *pb.KV) (*pb.KV, error) {
+	s := g.s
+	s.lock.RLock()
+	ns, err := s.namespace(in.Namespace)
+	s.lock.RUnlock()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if limit := s.valueLimit(ns); len(in.Value) > limit {
+		return nil, status.Errorf(codes.ResourceExhausted, "value of %d bytes exceeds limit of %d", len(in.Value), limit)
+	}
+	out, err := s.write(ctx, kvFromPB(in))
+	return kvToPB(out), grpcError(err)
+}
+
+func (g grpcService) Delete(ctx context.Context, in *pb.KV) (*pb.KV, error) {
+	out, err := g.s.delete(ctx, kvFromPB(in))
+	return kvToPB(out), grpcError(err)
+}
+
+func (g grpcService) Gossip(ctx context.Context, in *pb.Gossip) (*pb.GossipResponse, error) {
+	if err := g.s.authorizeGRPCPeer(ctx); err != nil {
+		return nil, err
+	}
+	cols, err := columnsFromPB(in.Columns)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	acks, err := acksFromPB(in.Acks)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	out, err := g.s.recvGossip(ctx, Gossip{
+		Host:       in.Host,
+		Columns:    cols,
+		Acks:       acks,
+		PushOnly:   in.PushOnly,
+		Delta:      in.Delta,
+		Namespaces: namespaceChangesFromPB(in.Namespaces),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.GossipResponse{
+		Columns:    columnsToPB(out.Columns),
+		Acks:       acksToPB(out.Acks),
+		Delta:      out.Delta,
+		Namespaces: namespaceChangesToPB(out.Namespaces),
+	}, nil
+}
+
+func (g grpcService) ViewChange(ctx context.Context, in *pb.ViewChange) (*pb.Empty, error) {
+	_, err := g.s.viewChange(ctx, rpcPrincipal(ctx), ViewChange{
+		Replicas:     in.Replicas,
+		DoNotForward: in.DoNotForward,
+		Origin:       in.Origin,
+		Principal:    in.Principal,
+		Issued:       timeFromPB(in.Issued),
+		Signature:    in.Signature,
+	})
+	return &pb.Empty{}, grpcError(err)
+}
+
+func (g grpcService) PutNamespace(ctx context.Context, in *pb.NamespaceChange) (*pb.Namespace, error) {
+	out, err := g.s.putNamespace(ctx, namespaceChangeFromPB(in))
+	return namespaceToPB(out), grpcError(err)
+}
+
+func (g grpcService) DeleteNamespace(ctx context.Context, in *pb.NamespaceChange) (*pb.Empty, error) {
+	_, err := g.s.deleteNamespace(ctx, namespaceChangeFromPB(in))
+	return &pb.Empty{}, grpcError(err)
+}
+
+// authorizeGRPCPeer checks the TLS identity of the caller with
+// authorizePeer.
+func (s *Server) authorizeGRPCPeer(ctx context.Context) error {
+	return grpcError(s.authorizePeer(connState(ctx), nil))
+}
+
+// grpcError converts an HttpError to a gRPC status.
+func grpcError(err error) error {
+	var withcode HttpError
+	if err == nil || !errors.As(err, &withcode) {
+		return err
+	}
+	code := codes.Unknown
+	switch withcode.Code() {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.Aborted
+	case http.StatusPreconditionFailed:
+		code = codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
+
+// grpcPeer returns a client for a peer that uses the gRPC transport.
+// Connections are kept for the life of the server.
+func (s *Server) grpcPeer(peer *url.URL) (pb.OkayVClient, error) {
+	s.connlock.Lock()
+	defer s.connlock.Unlock()
+	if conn, ok := s.conns[peer.Host]; ok {
+		return pb.NewOkayVClient(conn), nil
+	}
+	opts := s.GRPCDialOptions
+	if opts == nil {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	opts = append(opts[:len(opts):len(opts)],
+		grpc.WithStatsHandler(gossipStatsHandler{s}),
+		grpc.WithChainUnaryInterceptor(traceClientInterceptor))
+	if s.PeerSigner != nil {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(s.signInterceptor))
+	}
+	conn, err := grpc.NewClient("passthrough:///"+peer.Host, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.conns[peer.Host] = conn
+	return pb.NewOkayVClient(conn), nil
+}
+
+func (s *Server) gossipGRPC(ctx context.Context, dst *url.URL, in Gossip) (GossipResponse, error) {
+	client, err := s.grpcPeer(dst)
+	if err != nil {
+		return GossipResponse{}, err
+	}
+	var callopts []grpc.CallOption
+	if s.GossipCompression != "" {
+		callopts = append(callopts, grpc.UseCompressor(s.GossipCompression))
+	}
+	resp, err := client.Gossip(ctx, &pb.Gossip{
+		Host:       in.Host,
+		Columns:    columnsToPB(in.Columns),
+		Acks:       acksToPB(in.Acks),
+		PushOnly:   in.PushOnly,
+		Delta:      in.Delta,
+		Namespaces: namespaceChangesToPB(in.Namespaces),
+	}, callopts...)
+	if err != nil {
+		return GossipResponse{}, err
+	}
+	cols, err := columnsFromPB(resp.Columns)
+	if err != nil {
+		return GossipResponse{}, err
+	}
+	acks, err := acksFromPB(resp.Acks)
+	return GossipResponse{
+		Columns:    cols,
+		Acks:       acks,
+		Delta:      resp.Delta,
+		Namespaces: namespaceChangesFromPB(resp.Namespaces),
+	}, err
+}
+
+func (s *Server) viewChangeGRPC(ctx context.Context, dst *url.URL, in ViewChange) error {
+	client, err := s.grpcPeer(dst)
+	if err != nil {
+		return err
+	}
+	_, err = client.ViewChange(ctx, &pb.ViewChange{
+		Replicas:     in.Replicas,
+		DoNotForward: in.DoNotForward,
+		Origin:       in.Origin,
+		Principal:    in.Principal,
+		Issued:       timeToPB(in.Issued),
+		Signature:    in.Signature,
+	})
+	return err
+}
+
+func (s *Server) namespaceGRPC(ctx context.Context, method string, dst *url.URL, in NamespaceChange) error {
+	client, err := s.grpcPeer(dst)
+	if err != nil {
+		return err
+	}
+	req := namespaceChangeToPB(in)
+	switch method {
+	case http.MethodPut:
+		_, err = client.PutNamespace(ctx, req)
+	case http.MethodDelete:
+		_, err = client.DeleteNamespace(ctx, req)
+	default:
+		err = fmt.Errorf("cannot forward %s of a namespace", method)
+	}
+	return err
+}
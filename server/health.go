@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Status describes a server for operators.
+type Status struct {
+	Name   string      `json:"name"`
+	Peers  []string    `json:"peers"`
+	View   []string    `json:"view"`
+	MaxCC  VectorClock `json:"maxcc"`
+	Events int         `json:"events"`
+	// Keys counts the live keys of all namespaces.
+	Keys   int      `json:"keys"`
+	Uptime Duration `json:"uptime"`
+}
+
+// Status returns the current status of the server.
+func (s *Server) Status() Status {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	status := Status{
+		Name:   s.Name,
+		Peers:  make([]string, len(s.peers)),
+		View:   append([]string{}, s.view...),
+		MaxCC:  s.maxcc.Clone(),
+		Events: len(s.events),
+		Uptime: Duration(time.Since(s.started)),
+	}
+	for i, peer := range s.peers {
+		status.Peers[i] = peer.Host
+	}
+	for k := range s.latest {
+		if _, ok := s.lookup(k); ok {
+			status.Keys++
+		}
+	}
+	return status
+}
+
+func (s *Server) status(struct{}) (Status, error) {
+	return s.Status(), nil
+}
+
+// Ready returns an error unless the server has installed a view, has
+// gossiped with a peer in it within MaxLag, and is not missing events that a
+// peer has shown it. A draining server is never ready.
+func (s *Server) Ready() error {
+	if s.draining.Load() {
+		return fmt.Errorf("shutting down")
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.view == nil {
+		return fmt.Errorf("no view installed")
+	}
+	if len(s.peers) == 0 {
+		return nil
+	}
+	now := time.Now()
+	var last time.Time
+	for _, peer := range s.peers {
+		lag := s.peerLag(peer.Host, now)
+		if len(lag.Behind) > 0 {
+			return fmt.Errorf("missing events %v from %s", lag.Behind, peer.Host)
+		}
+		if lag.LastGossip.After(last) {
+			last = lag.LastGossip
+		}
+	}
+	if last.IsZero() {
+		return fmt.Errorf("not bootstrapped from a peer")
+	}
+	maxLag := s.MaxLag
+	if maxLag == 0 {
+		maxLag = DefaultMaxLagRounds * s.GossipFreq
+	}
+	if since := now.Sub(last); maxLag > 0 && since > maxLag {
+		return fmt.Errorf("no gossip with a peer for %v", since)
+	}
+	return nil
+}
+
+func (s *Server) healthz(struct{}) (struct{}, error) {
+	return struct{}{}, nil
+}
+
+func (s *Server) readyz(struct{}) (struct{}, error) {
+	if err := s.Ready(); err != nil {
+		return struct{}{}, newerr(http.StatusServiceUnavailable, err)
+	}
+	return struct{}{}, nil
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// SupersededByCausality marks a version overwritten by a write that
+	// happened after it.
+	SupersededByCausality = "causality"
+	// SupersededByTieBreak marks a version that lost a timestamp tie-break
+	// against a concurrent write.
+	SupersededByTieBreak = "tie-break"
+)
+
+type Version struct {
+	Value       []byte      `json:"value"`
+	ContentType string      `json:"content-type,omitempty"`
+	Clock       CausalClock `json:"clock"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Origin      string      `json:"origin"`
+	Superseded  string      `json:"superseded,omitempty"`
+	// Dropped versions were never appended to this replica's log.
+	Dropped bool `json:"dropped,omitempty"`
+	// Deleted versions are tombstones.
+	Deleted bool `json:"deleted,omitempty"`
+	// Trace is the traceparent of the request that wrote the version.
+	Trace string `json:"trace,omitempty"`
+}
+
+type History struct {
+	Namespace string    `json:"namespace,omitempty"`
+	Key       string    `json:"key"`
+	Versions  []Version `json:"versions"`
+}
+
+// history returns every version of a key known to this replica, oldest first.
+// Versions dropped by a tie-break are listed after the retained ones.
+func (s *Server) history(in KV) (History, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	s.Info("History", "key", in.Key)
+
+	if _, err := s.namespace(in.Namespace); err != nil {
+		return History{}, err
+	}
+	k := nskey{in.Namespace, in.Key}
+	result := History{Namespace: in.Namespace, Key: in.Key}
+	latest, ok := s.latest[k]
+	if !ok {
+		return result, newerr(http.StatusNotFound, fmt.Errorf("history %s: does not exist", in.Key))
+	}
+
+	live := map[int]nothing{latest: {}}
+	for _, idx := range s.siblings[k] {
+		live[idx] = nothing{}
+	}
+	for i, col := range s.events {
+		if col.nskey() != k {
+			continue
+		}
+		v := Version{
+			Value:       col.Value,
+			ContentType: col.ContentType,
+			Clock:       col.Clock.Clone(),
+			Timestamp:   col.Timestamp,
+			Origin:      col.Origin,
+			Deleted:     col.Deleted,
+			Trace:       col.Trace,
+		}
+		if _, ok := live[i]; !ok {
+			v.Superseded = SupersededByCausality
+			if _, lost := s.tiebroken[col.Clock.ID.String()]; lost {
+				v.Superseded = SupersededByTieBreak
+			}
+		}
+		result.Versions = append(result.Versions, v)
+	}
+
+	for _, col := range s.dropped {
+		if col.nskey() != k {
+			continue
+		}
+		result.Versions = append(result.Versions, Version{
+			Value:       col.Value,
+			ContentType: col.ContentType,
+			Clock:       col.Clock.Clone(),
+			Timestamp:   col.Timestamp,
+			Origin:      col.Origin,
+			Superseded:  SupersededByTieBreak,
+			Dropped:     true,
+			Deleted:     col.Deleted,
+			Trace:       col.Trace,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// index maps the value found at a JSON path to the keys that hold it. Values
+// are compared by their canonical JSON encoding.
+type index struct {
+	entries map[string]map[string]nothing
+	byKey   map[string][]string
+}
+
+func newIndex() *index {
+	return &index{
+		entries: make(map[string]map[string]nothing),
+		byKey:   make(map[string][]string),
+	}
+}
+
+func (ix *index) remove(key string) {
+	for _, value := range ix.byKey[key] {
+		delete(ix.entries[value], key)
+		if len(ix.entries[value]) == 0 {
+			delete(ix.entries, value)
+		}
+	}
+	delete(ix.byKey, key)
+}
+
+func (ix *index) add(key, value string) {
+	keys, ok := ix.entries[value]
+	if !ok {
+		keys = make(map[string]nothing)
+		ix.entries[value] = keys
+	}
+	keys[key] = nothing{}
+	ix.byKey[key] = append(ix.byKey[key], value)
+}
+
+// extract finds the value at a dotted JSON path such as "user.emails.0" and
+// returns its canonical encoding.
+func extract(doc []byte, path string) (string, bool) {
+	var cur any
+	if err := json.Unmarshal(doc, &cur); err != nil {
+		return "", false
+	}
+	for _, segment := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return "", false
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", false
+			}
+			cur = v[i]
+		default:
+			return "", false
+		}
+	}
+	return canonical(cur)
+}
+
+func canonical(v any) (string, bool) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(buf), true
+}
+
+// reindex updates every index of k's namespace with the current versions of
+// k.
+// reindex assumes the write lock is held.
+func (s *Server) reindex(k nskey) {
+	for path, ix := range s.indexes[k.Namespace] {
+		ix.remove(k.Key)
+		for _, idx := range s.live(k) {
+			if value, ok := extract(s.events[idx].Value, path); ok {
+				ix.add(k.Key, value)
+			}
+		}
+	}
+}
+
+// live returns the indices of the current versions of k.
+func (s *Server) live(k nskey) []int {
+	if siblings := s.siblings[k]; len(siblings) > 0 {
+		return siblings
+	}
+	if idx, ok := s.latest[k]; ok {
+		return []int{idx}
+	}
+	return nil
+}
+
+// buildIndexes replaces the indexes of a namespace with new ones built from
+// its current keys.
+// buildIndexes assumes the write lock is held.
+func (s *Server) buildIndexes(ns Namespace) {
+	if len(ns.Indexes) == 0 {
+		delete(s.indexes, ns.Name)
+		return
+	}
+	indexes := make(map[string]*index, len(ns.Indexes))
+	for _, path := range ns.Indexes {
+		indexes[path] = newIndex()
+	}
+	s.indexes[ns.Name] = indexes
+	for k := range s.latest {
+		if k.Namespace == ns.Name {
+			s.reindex(k)
+		}
+	}
+}
+
+type Query struct {
+	Namespace string          `json:"namespace,omitempty"`
+	Path      string          `json:"path"`
+	Value     json.RawMessage `json:"value"`
+	Context   VectorClock     `json:"causal-context,omitempty"`
+}
+
+type QueryResult struct {
+	Results []KV        `json:"results"`
+	Context VectorClock `json:"causal-context,omitempty"`
+}
+
+// query returns every key whose value has the given value at an indexed path.
+func (s *Server) query(in Query) (QueryResult, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	s.Info("Query", "ns", in.Namespace, "path", in.Path, "value", string(in.Value), "ctx", in.Context)
+
+	if err := s.behind(in.Context); err != nil {
+		return QueryResult{}, err
+	}
+	if _, err := s.namespace(in.Namespace); err != nil {
+		return QueryResult{}, err
+	}
+	ix, ok := s.indexes[in.Namespace][in.Path]
+	if !ok {
+		return QueryResult{}, newerr(http.StatusBadRequest, fmt.Errorf("path %q is not indexed", in.Path))
+	}
+	var want any
+	if err := json.Unmarshal(in.Value, &want); err != nil {
+		return QueryResult{}, newerr(http.StatusBadRequest, fmt.Errorf("invalid value: %v", err))
+	}
+	value, _ := canonical(want)
+
+	keys := make([]string, 0, len(ix.entries[value]))
+	for key := range ix.entries[value] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := QueryResult{
+		Results: []KV{},
+		Context: in.Context.Clone(),
+	}
+	now := time.Now()
+	for _, key := range keys {
+		for _, idx := range s.live(nskey{in.Namespace, key}) {
+			col := s.events[idx]
+			if col.expired(now) {
+				continue
+			}
+			if v, ok := extract(col.Value, in.Path); !ok || v != value {
+				continue
+			}
+			ctx := col.Clock.Context.Clone()
+			ctx.TakeMax(in.Context)
+			result.Results = append(result.Results, KV{
+				Namespace:   col.Namespace,
+				Key:         col.Key,
+				Value:       col.Value,
+				ContentType: col.ContentType,
+				Context:     ctx,
+			})
+			result.Context.TakeMax(col.Clock.Context)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// JoinRequest asks a replica to merge the replicas its sender knows of,
+// including the sender, into its view. The reply is the merged view.
+//
+// A joining node sends Replicas. A replica whose view grew sends its peers
+// Change instead, a view change signed by it as the origin, which they verify
+// before they merge it.
+type JoinRequest struct {
+	Replicas []string    `json:"replicas,omitempty"`
+	Change   *ViewChange `json:"change,omitempty"`
+}
+
+// Join asks the seeds in turn to merge this node into their view until one
+// does, retrying each gossip round until ctx is done. Without seeds other than
+// itself, the node installs a view of only itself.
+func (s *Server) Join(ctx context.Context) error {
+	var seeds []string
+	for _, seed := range s.Seeds {
+		addr, err := url.Parse(seed)
+		if err != nil {
+			return err
+		}
+		if addr.Host != s.Name {
+			seeds = append(seeds, seed)
+		}
+	}
+	if len(seeds) == 0 {
+		_, err := s.mergeView(ctx, s.Name, nil)
+		return err
+	}
+	for {
+		for _, seed := range seeds {
+			s.Info("Joining", "seed", seed)
+			var view []string
+			err := s.forward(ctx, http.MethodPost, seed, "/join", JoinRequest{Replicas: s.viewWithSelf()}, &view)
+			if err != nil {
+				s.Warn("Seed did not accept join", "seed", seed, "err", err)
+				continue
+			}
+			_, err = s.mergeView(ctx, s.Name, view)
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no seed accepted the join: %w", ctx.Err())
+		case <-time.After(s.gossipFreq()):
+		}
+	}
+}
+
+func (s *Server) join(ctx context.Context, principal string, in JoinRequest) (_ []string, err error) {
+	replicas := in.Replicas
+	if in.Change != nil {
+		// The change is only ever sent on by its origin.
+		change := *in.Change
+		change.DoNotForward = true
+		defer func() { s.audit(principal, change, err) }()
+		if err := s.authorizePeer(connState(ctx), change.Replicas); err != nil {
+			return nil, err
+		}
+		if err := s.verifyViewChange(change); err != nil {
+			return nil, err
+		}
+		replicas = change.Replicas
+	} else if err := s.authorizeJoin(connState(ctx), replicas); err != nil {
+		return nil, err
+	}
+	return s.mergeView(ctx, principal, replicas)
+}
+
+// viewWithSelf returns the view with this node in it.
+func (s *Server) viewWithSelf() []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	view, _, _ := s.union(nil)
+	return view
+}
+
+// mergeView adds replicas to the view. If it grew, the merged view is signed
+// and sent to every other replica, which merge it and send it on in turn if
+// their view grew, so that replicas that joined through different seeds
+// converge on one view. It returns the view.
+func (s *Server) mergeView(ctx context.Context, principal string, replicas []string) ([]string, error) {
+	s.lock.Lock()
+	view, peers, err := s.union(replicas)
+	grew := err == nil && len(view) > len(s.view)
+	var change ViewChange
+	if grew {
+		s.setView(view, peers)
+		// Changes are signed under the lock, so that a later change from
+		// this origin is never issued before an earlier, smaller one.
+		change = s.originate(principal, ViewChange{Replicas: view, DoNotForward: true})
+	}
+	s.lock.Unlock()
+	if err != nil {
+		return nil, newerr(http.StatusBadRequest, err)
+	}
+	if !grew {
+		return view, nil
+	}
+	s.audit(principal, change, nil)
+
+	for _, peer := range peers {
+		if err := s.forward(ctx, http.MethodPost, peer.String(), "/join", JoinRequest{Change: &change}, nil); err != nil {
+			s.Warn("Failed to send view", "dst", peer.Host, "err", err)
+		}
+	}
+	return s.viewWithSelf(), nil
+}
+
+// union returns the view with replicas and this node added, sorted, and the
+// peers in it.
+// Replicas are identified by their host, so a replica already in the view
+// keeps its URL. It assumes the read lock is held.
+func (s *Server) union(replicas []string) ([]string, []*url.URL, error) {
+	byHost := make(map[string]string)
+	all := append(slices.Clone(s.view), s.Advertise)
+	for _, replica := range append(all, replicas...) {
+		addr, err := url.Parse(replica)
+		if err != nil {
+			return nil, nil, err
+		}
+		if addr.Host == "" {
+			return nil, nil, fmt.Errorf("replica %q has no host", replica)
+		}
+		if _, ok := byHost[addr.Host]; !ok {
+			byHost[addr.Host] = replica
+		}
+	}
+	var view []string
+	for _, replica := range byHost {
+		view = append(view, replica)
+	}
+	slices.Sort(view)
+	var peers []*url.URL
+	for _, replica := range view {
+		addr, _ := url.Parse(replica)
+		if addr.Host != s.Name {
+			peers = append(peers, addr)
+		}
+	}
+	return view, peers, nil
+}
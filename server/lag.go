@@ -0,0 +1,74 @@
+package server
+
+import "time"
+
+// PeerLag reports how far a peer is behind the server, and the server
+// behind the peer.
+type PeerLag struct {
+	Peer string `json:"peer"`
+	// Unreplicated counts the events the peer has not acknowledged, and
+	// OldestUnreplicated is the age of the oldest of them.
+	Unreplicated       int      `json:"unreplicated"`
+	OldestUnreplicated Duration `json:"oldest-unreplicated"`
+	// LastGossip is when the server last gossiped with the peer in either
+	// direction. It is zero if it never has.
+	LastGossip time.Time `json:"last-gossip"`
+	// Ahead counts the events the server has that the peer has not shown
+	// it has, and Behind the events the peer has shown that the server is
+	// missing, by node.
+	Ahead  VectorClock `json:"ahead"`
+	Behind VectorClock `json:"behind"`
+}
+
+// ReplicationLag returns the lag of each peer.
+func (s *Server) ReplicationLag() []PeerLag {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	now := time.Now()
+	lags := make([]PeerLag, len(s.peers))
+	for i, peer := range s.peers {
+		lags[i] = s.peerLag(peer.Host, now)
+	}
+	return lags
+}
+
+func (s *Server) replicationLag(struct{}) ([]PeerLag, error) {
+	return s.ReplicationLag(), nil
+}
+
+// peerLag assumes the read lock is held.
+func (s *Server) peerLag(remote string, now time.Time) PeerLag {
+	lag := PeerLag{
+		Peer:       remote,
+		LastGossip: s.lastGossip[remote],
+		Ahead:      s.maxcc.Minus(s.learned[remote]),
+		Behind:     s.learned[remote].Minus(s.maxcc),
+	}
+	for i := s.acked[remote]; i < len(s.events); i++ {
+		if _, acked := s.events[i].Clock.Replicated[remote]; acked {
+			continue
+		}
+		if lag.Unreplicated == 0 {
+			lag.OldestUnreplicated = Duration(now.Sub(s.events[i].Timestamp))
+		}
+		lag.Unreplicated++
+	}
+	return lag
+}
+
+// learn raises the context remote has shown the server to ctx.
+// learn assumes the write lock is held.
+func (s *Server) learn(remote string, ctx VectorClock) {
+	learned := s.learned[remote]
+	learned.TakeMax(ctx)
+	s.learned[remote] = learned
+}
+
+// learnAcked raises the context of remote to include the columns the server
+// acked to it, since logging them counted as events of the server.
+// learnAcked assumes the write lock is held.
+func (s *Server) learnAcked(remote string, acked []Column) {
+	for _, col := range acked {
+		s.learn(remote, col.Clock.Context)
+	}
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics are the Prometheus metrics of a server. Each server has its own
+// registry so that several can run in one process.
+type metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	behind          prometheus.Counter
+	gossipRounds    *prometheus.CounterVec
+	columnsSent     prometheus.Counter
+	columnsReceived prometheus.Counter
+	ackStalls       prometheus.Counter
+	tiebreakDrops   prometheus.Counter
+}
+
+func newMetrics(s *Server) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "okayv_requests_total",
+			Help: "Requests served by route and status code.",
+		}, []string{"route", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "okayv_request_duration_seconds",
+			Help:    "Latency of requests by route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route"}),
+		behind: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "okayv_unavailable_total",
+			Help: "Requests refused with 503 because their context was ahead of the server.",
+		}),
+		gossipRounds: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "okayv_gossip_rounds_total",
+			Help: "Gossip rounds with each peer by result.",
+		}, []string{"peer", "result"}),
+		columnsSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "okayv_gossip_columns_sent_total",
+			Help: "Columns sent to peers in gossip.",
+		}),
+		columnsReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "okayv_gossip_columns_received_total",
+			Help: "Columns received from peers in gossip.",
+		}),
+		ackStalls: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "okayv_gossip_ack_stalls_total",
+			Help: "Gossip batches that stopped at a column too far ahead to accept.",
+		}),
+		tiebreakDrops: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "okayv_tiebreak_drops_total",
+			Help: "Remote writes dropped for losing a timestamp tie-break.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.behind,
+		m.gossipRounds,
+		m.columnsSent,
+		m.columnsReceived,
+		m.ackStalls,
+		m.tiebreakDrops,
+		gossipBytes("okayv_gossip_sent_bytes_total", "Bytes of gossip sent before compression.", s.stats.sent.Load),
+		gossipBytes("okayv_gossip_sent_wire_bytes_total", "Bytes of gossip sent after compression.", s.stats.sentWire.Load),
+		gossipBytes("okayv_gossip_received_bytes_total", "Bytes of gossip received after decompression.", s.stats.received.Load),
+		gossipBytes("okayv_gossip_received_wire_bytes_total", "Bytes of gossip received before decompression.", s.stats.receivedWire.Load),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "okayv_events",
+			Help: "Events in the log.",
+		}, func() float64 {
+			s.lock.RLock()
+			defer s.lock.RUnlock()
+			return float64(len(s.events))
+		}),
+		lagCollector{s},
+	)
+	return m
+}
+
+func gossipBytes(name, help string, load func() int64) prometheus.Collector {
+	return prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 {
+		return float64(load())
+	})
+}
+
+var (
+	unackedDesc    = prometheus.NewDesc("okayv_unacked_events", "Events not yet acknowledged by each peer.", []string{"peer"}, nil)
+	oldestDesc     = prometheus.NewDesc("okayv_oldest_unacked_seconds", "Age of the oldest event not yet acknowledged by each peer.", []string{"peer"}, nil)
+	lastGossipDesc = prometheus.NewDesc("okayv_last_gossip_timestamp_seconds", "Time of the last successful gossip with each peer.", []string{"peer"}, nil)
+	behindDesc     = prometheus.NewDesc("okayv_behind_events", "Events each peer has shown that the server is missing.", []string{"peer"}, nil)
+)
+
+// lagCollector reports the replication lag of each peer.
+type lagCollector struct {
+	s *Server
+}
+
+func (c lagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- unackedDesc
+	ch <- oldestDesc
+	ch <- lastGossipDesc
+	ch <- behindDesc
+}
+
+func (c lagCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, lag := range c.s.ReplicationLag() {
+		behind := 0
+		for _, n := range lag.Behind {
+			behind += n
+		}
+		var last float64
+		if !lag.LastGossip.IsZero() {
+			last = float64(lag.LastGossip.UnixNano()) / 1e9
+		}
+		ch <- prometheus.MustNewConstMetric(unackedDesc, prometheus.GaugeValue, float64(lag.Unreplicated), lag.Peer)
+		ch <- prometheus.MustNewConstMetric(oldestDesc, prometheus.GaugeValue, time.Duration(lag.OldestUnreplicated).Seconds(), lag.Peer)
+		ch <- prometheus.MustNewConstMetric(lastGossipDesc, prometheus.GaugeValue, last, lag.Peer)
+		ch <- prometheus.MustNewConstMetric(behindDesc, prometheus.GaugeValue, float64(behind), lag.Peer)
+	}
+}
+
+// Metrics returns the registry of the server's metrics.
+func (s *Server) Metrics() *prometheus.Registry {
+	return s.metrics.registry
+}
+
+// instrument counts the requests to route and their latencies, and traces
+// them.
+func (s *Server) instrument(route string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r, span := s.traceRequest(r, route)
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		h(sw, r)
+		endRequest(span, sw.code)
+		s.metrics.requests.WithLabelValues(route, strconv.Itoa(sw.code)).Inc()
+		s.metrics.requestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// statusWriter remembers the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (s *Server) metricsHandler() http.Handler {
+	return promhttp.HandlerFor(s.metrics.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultNamespace is the namespace used by requests that do not name one. It
+// always exists.
+const DefaultNamespace = ""
+
+type ConflictPolicy string
+
+const (
+	// PolicyLWW resolves concurrent writes by timestamp. It is the default.
+	PolicyLWW ConflictPolicy = "lww"
+	// PolicySiblings keeps concurrent writes and returns all of them on read
+	// until a later write supersedes them.
+	PolicySiblings ConflictPolicy = "siblings"
+)
+
+type Namespace struct {
+	Name   string         `json:"name"`
+	Policy ConflictPolicy `json:"policy,omitempty"`
+	// TTL is the default time to live of writes. Zero never expires.
+	TTL Duration `json:"ttl,omitempty"`
+	// MaxValueSize overrides the server's value size limit when non-zero.
+	MaxValueSize int `json:"max-value-size,omitempty"`
+	// ReplicationFactor is the number of replicas that must hold a write
+	// before it is acknowledged with 200. Writes that reach fewer replicas
+	// are kept, marked pending and acknowledged with 202.
+	ReplicationFactor int `json:"replication-factor,omitempty"`
+	// Indexes lists dotted JSON paths, such as "user.email", to index values
+	// by. Values that are not JSON documents are not indexed.
+	Indexes []string `json:"indexes,omitempty"`
+}
+
+type NamespaceChange struct {
+	Namespace
+	DoNotForward bool `json:"donotforward,omitempty"`
+	// Deleted marks the deletion of the namespace.
+	Deleted bool `json:"deleted,omitempty"`
+	// Version orders the changes of a namespace. It is the time of the
+	// change on Origin, its first replica, in nanoseconds.
+	Version int64  `json:"version,omitempty"`
+	Origin  string `json:"origin,omitempty"`
+}
+
+// newer reports whether c supersedes prev. Ties are broken by origin.
+func (c NamespaceChange) newer(prev NamespaceChange) bool {
+	if c.Version != prev.Version {
+		return c.Version > prev.Version
+	}
+	return c.Origin > prev.Origin
+}
+
+// Duration is a time.Duration that is encoded in JSON as a string such as
+// "1m30s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(buf []byte) error {
+	var str string
+	if err := json.Unmarshal(buf, &str); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (ns Namespace) validate() error {
+	switch ns.Policy {
+	case "", PolicyLWW, PolicySiblings:
+	default:
+		return fmt.Errorf("unknown conflict policy %q", ns.Policy)
+	}
+	if ns.TTL < 0 || ns.MaxValueSize < 0 || ns.ReplicationFactor < 0 {
+		return fmt.Errorf("namespace settings cannot be negative")
+	}
+	for _, path := range ns.Indexes {
+		if path == "" {
+			return fmt.Errorf("index path cannot be empty")
+		}
+	}
+	return nil
+}
+
+// namespace returns the settings of the named namespace.
+// namespace assumes the read lock is held.
+func (s *Server) namespace(name string) (Namespace, error) {
+	ns, ok := s.namespaces[name]
+	if !ok {
+		return Namespace{}, newerr(http.StatusNotFound, fmt.Errorf("namespace %q does not exist", name))
+	}
+	return ns, nil
+}
+
+func (s *Server) valueLimit(ns Namespace) int {
+	if ns.MaxValueSize > 0 {
+		return ns.MaxValueSize
+	}
+	return s.MaxValueSize
+}
+
+func (s *Server) listNamespaces(nothing) ([]Namespace, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	result := make([]Namespace, 0, len(s.namespaces))
+	for _, ns := range s.namespaces {
+		result = append(result, ns)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// namespaceForwardTimeout bounds forwarding a namespace change to its peers.
+const namespaceForwardTimeout = 5 * time.Second
+
+// putNamespace creates a namespace or replaces its settings.
+func (s *Server) putNamespace(ctx context.Context, in NamespaceChange) (Namespace, error) {
+	s.Info("Receiving namespace", "name", in.Name)
+	if err := in.validate(); err != nil {
+		return Namespace{}, newerr(http.StatusBadRequest, err)
+	}
+	in.Deleted = false
+	s.changeNamespace(ctx, http.MethodPut, in)
+	return in.Namespace, nil
+}
+
+// deleteNamespace removes a namespace and makes its keys unreadable. The
+// history of the keys is kept.
+func (s *Server) deleteNamespace(ctx context.Context, in NamespaceChange) (nothing, error) {
+	s.Info("Deleting namespace", "name", in.Name)
+	if in.Name == DefaultNamespace {
+		return nothing{}, newerr(http.StatusBadRequest, fmt.Errorf("cannot delete the default namespace"))
+	}
+	in.Namespace = Namespace{Name: in.Name}
+	in.Deleted = true
+	s.changeNamespace(ctx, http.MethodDelete, in)
+	return nothing{}, nil
+}
+
+// changeNamespace versions a change at its origin, applies it and forwards it
+// to every peer. Peers that miss it learn it through gossip.
+func (s *Server) changeNamespace(ctx context.Context, method string, in NamespaceChange) {
+	s.lock.Lock()
+	if in.Version == 0 {
+		in.Origin = s.Name
+		in.Version = time.Now().UnixNano()
+		if prev, ok := s.nsChanges[in.Name]; ok && prev.Version >= in.Version {
+			in.Version = prev.Version + 1
+		}
+	}
+	s.playNamespaces([]NamespaceChange{in})
+	s.lock.Unlock()
+	if in.DoNotForward {
+		return
+	}
+
+	in.DoNotForward = true
+	ctx, cancel := context.WithTimeout(ctx, namespaceForwardTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, peer := range s.peerList() {
+		wg.Add(1)
+		go func(peer *url.URL) {
+			defer wg.Done()
+			s.Info("Forwarding namespace change", "dst", peer, "deleted", in.Deleted)
+			if err := s.forwardNamespace(ctx, method, peer, in); err != nil {
+				s.Warn("Failed to forward namespace change, leaving it to gossip", "dst", peer, "err", err)
+			}
+		}(peer)
+	}
+	wg.Wait()
+}
+
+// playNamespaces applies the changes that are newer than the ones known.
+// playNamespaces assumes the write lock is held.
+func (s *Server) playNamespaces(changes []NamespaceChange) {
+	for _, in := range changes {
+		if in.Name == DefaultNamespace {
+			continue
+		}
+		if prev, ok := s.nsChanges[in.Name]; ok && !in.newer(prev) {
+			continue
+		}
+		in.DoNotForward = false
+		s.nsChanges[in.Name] = in
+		if !in.Deleted {
+			s.namespaces[in.Name] = in.Namespace
+			s.buildIndexes(in.Namespace)
+			continue
+		}
+		delete(s.namespaces, in.Name)
+		delete(s.indexes, in.Name)
+		for k := range s.latest {
+			if k.Namespace == in.Name {
+				delete(s.latest, k)
+				delete(s.siblings, k)
+			}
+		}
+	}
+}
+
+// namespaceChanges returns the latest change of every namespace, to gossip.
+// namespaceChanges assumes the read lock is held.
+func (s *Server) namespaceChanges() []NamespaceChange {
+	changes := make([]NamespaceChange, 0, len(s.nsChanges))
+	for _, change := range s.nsChanges {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// deleted reports whether the namespace was deleted.
+// deleted assumes the read lock is held.
+func (s *Server) deleted(namespace string) bool {
+	return s.nsChanges[namespace].Deleted
+}
+
+func (s *Server) forwardNamespace(ctx context.Context, method string, peer *url.URL, in NamespaceChange) error {
+	if peer.Scheme == GRPCScheme {
+		return s.namespaceGRPC(ctx, method, peer, in)
+	}
+	return s.forward(ctx, method, peer.String(), "/namespaces", in, nil)
+}
+
+// replicate pushes a fresh write or delete to peers until it is held by rf
+// replicas, and returns the number of replicas known to hold it.
+// replicate must be called without the lock held.
+func (s *Server) replicate(ctx context.Context, out KV, rf int) int {
+	k := nskey{out.Namespace, out.Key}
+	replicas := func() int {
+		s.lock.RLock()
+		defer s.lock.RUnlock()
+		col, ok := s.version(k)
+		if !ok {
+			return 0
+		}
+		return len(col.Clock.Replicated)
+	}
+
+	n := replicas()
+	peers := s.peerList()
+	for _, i := range rand.Perm(len(peers)) {
+		if n >= rf {
+			break
+		}
+		if err := s.gossipMode(ctx, peers[i], GossipPush); err != nil {
+			s.Warn("Failed to replicate write", "dst", peers[i], "err", err)
+		}
+		n = replicas()
+	}
+	return n
+}
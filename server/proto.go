@@ -0,0 +1,309 @@
+package server
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spencer-p/okayv/pb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Conversions between the server's types and their protobuf encodings. Zero
+// times and durations are encoded as absent fields.
+
+func clockToPB(v VectorClock) map[string]int64 {
+	if v == nil {
+		return nil
+	}
+	out := make(map[string]int64, len(v))
+	for node, ctr := range v {
+		out[node] = int64(ctr)
+	}
+	return out
+}
+
+func clockFromPB(m map[string]int64) VectorClock {
+	if m == nil {
+		return nil
+	}
+	out := make(VectorClock, len(m))
+	for node, ctr := range m {
+		out[node] = int(ctr)
+	}
+	return out
+}
+
+func timeToPB(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timeFromPB(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+func durationToPB(d Duration) *durationpb.Duration {
+	if d == 0 {
+		return nil
+	}
+	return durationpb.New(time.Duration(d))
+}
+
+func durationFromPB(d *durationpb.Duration) Duration {
+	if d == nil {
+		return 0
+	}
+	return Duration(d.AsDuration())
+}
+
+func setToPB(set map[string]nothing) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func setFromPB(list []string) map[string]nothing {
+	out := make(map[string]nothing, len(list))
+	for _, k := range list {
+		out[k] = nothing{}
+	}
+	return out
+}
+
+func crdtToPB(c *CRDT) *pb.CRDT {
+	if c == nil {
+		return nil
+	}
+	out := &pb.CRDT{
+		Type:        string(c.Type),
+		P:           c.P,
+		N:           c.N,
+		Removed:     setToPB(c.Removed),
+		Register:    c.Register,
+		ContentType: c.ContentType,
+		Time:        timeToPB(c.Time),
+		Replica:     c.Replica,
+	}
+	if len(c.Adds) > 0 {
+		out.Adds = make(map[string]*pb.Tags, len(c.Adds))
+		for elem, tags := range c.Adds {
+			out.Adds[elem] = &pb.Tags{Tags: setToPB(tags)}
+		}
+	}
+	return out
+}
+
+func crdtFromPB(c *pb.CRDT) *CRDT {
+	if c == nil {
+		return nil
+	}
+	out := &CRDT{
+		Type:        CRDTType(c.Type),
+		P:           c.P,
+		N:           c.N,
+		Removed:     setFromPB(c.Removed),
+		Register:    c.Register,
+		ContentType: c.ContentType,
+		Time:        timeFromPB(c.Time),
+		Replica:     c.Replica,
+	}
+	out.Adds = make(map[string]map[string]nothing, len(c.Adds))
+	for elem, tags := range c.Adds {
+		out.Adds[elem] = setFromPB(tags.GetTags())
+	}
+	return out
+}
+
+func columnToPB(col Column) *pb.Column {
+	return &pb.Column{
+		Namespace:   col.Namespace,
+		Key:         col.Key,
+		Value:       col.Value,
+		ContentType: col.ContentType,
+		Clock: &pb.CausalClock{
+			Id:         col.Clock.ID[:],
+			Context:    clockToPB(col.Clock.Context),
+			Replicated: setToPB(col.Clock.Replicated),
+			Origin:     clockToPB(col.Clock.Origin),
+		},
+		Timestamp: timeToPB(col.Timestamp),
+		Expires:   timeToPB(col.Expires),
+		Origin:    col.Origin,
+		Crdt:      crdtToPB(col.CRDT),
+		Deleted:   col.Deleted,
+		Trace:     col.Trace,
+	}
+}
+
+func columnFromPB(col *pb.Column) (Column, error) {
+	id, err := uuid.FromBytes(col.GetClock().GetId())
+	if err != nil {
+		return Column{}, err
+	}
+	return Column{
+		Namespace:   col.Namespace,
+		Key:         col.Key,
+		Value:       col.Value,
+		ContentType: col.ContentType,
+		Clock: CausalClock{
+			ID:         id,
+			Context:    clockFromPB(col.GetClock().GetContext()),
+			Replicated: setFromPB(col.GetClock().GetReplicated()),
+			Origin:     clockFromPB(col.GetClock().GetOrigin()),
+		},
+		Timestamp: timeFromPB(col.Timestamp),
+		Expires:   timeFromPB(col.Expires),
+		Origin:    col.Origin,
+		CRDT:      crdtFromPB(col.Crdt),
+		Deleted:   col.Deleted,
+		Trace:     col.Trace,
+	}, nil
+}
+
+func columnsToPB(cols []Column) []*pb.Column {
+	out := make([]*pb.Column, len(cols))
+	for i, col := range cols {
+		out[i] = columnToPB(col)
+	}
+	return out
+}
+
+func columnsFromPB(cols []*pb.Column) ([]Column, error) {
+	out := make([]Column, len(cols))
+	for i, col := range cols {
+		var err error
+		if out[i], err = columnFromPB(col); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func acksToPB(acks []Ack) []*pb.Ack {
+	out := make([]*pb.Ack, len(acks))
+	for i, a := range acks {
+		id := a.ID
+		out[i] = &pb.Ack{
+			Id:         id[:],
+			Seq:        int64(a.Seq),
+			Replicated: a.Replicated,
+		}
+	}
+	return out
+}
+
+func acksFromPB(acks []*pb.Ack) ([]Ack, error) {
+	out := make([]Ack, len(acks))
+	for i, a := range acks {
+		id, err := uuid.FromBytes(a.Id)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = Ack{ID: id, Seq: int(a.Seq), Replicated: a.Replicated}
+	}
+	return out, nil
+}
+
+func kvToPB(kv KV) *pb.KV {
+	return &pb.KV{
+		Namespace:   kv.Namespace,
+		Key:         kv.Key,
+		Value:       kv.Value,
+		ContentType: kv.ContentType,
+		Context:     clockToPB(kv.Context),
+		At:          clockToPB(kv.At),
+		Ttl:         durationToPB(kv.TTL),
+		Siblings:    kv.Siblings,
+		Version:     kv.Version,
+		IfMatch:     kv.IfMatch,
+	}
+}
+
+func kvFromPB(kv *pb.KV) KV {
+	return KV{
+		Namespace:   kv.Namespace,
+		Key:         kv.Key,
+		Value:       kv.Value,
+		ContentType: kv.ContentType,
+		Context:     clockFromPB(kv.Context),
+		At:          clockFromPB(kv.At),
+		TTL:         durationFromPB(kv.Ttl),
+		Siblings:    kv.Siblings,
+		Version:     kv.Version,
+		IfMatch:     kv.IfMatch,
+	}
+}
+
+func namespaceToPB(ns Namespace) *pb.Namespace {
+	return &pb.Namespace{
+		Name:              ns.Name,
+		Policy:            string(ns.Policy),
+		Ttl:               durationToPB(ns.TTL),
+		MaxValueSize:      int64(ns.MaxValueSize),
+		ReplicationFactor: int64(ns.ReplicationFactor),
+		Indexes:           ns.Indexes,
+	}
+}
+
+func namespaceFromPB(ns *pb.Namespace) Namespace {
+	return Namespace{
+		Name:              ns.GetName(),
+		Policy:            ConflictPolicy(ns.GetPolicy()),
+		TTL:               durationFromPB(ns.GetTtl()),
+		MaxValueSize:      int(ns.GetMaxValueSize()),
+		ReplicationFactor: int(ns.GetReplicationFactor()),
+		Indexes:           ns.GetIndexes(),
+	}
+}
+
+func namespaceChangeToPB(in NamespaceChange) *pb.NamespaceChange {
+	return &pb.NamespaceChange{
+		Namespace:    namespaceToPB(in.Namespace),
+		DoNotForward: in.DoNotForward,
+		Deleted:      in.Deleted,
+		Version:      in.Version,
+		Origin:       in.Origin,
+	}
+}
+
+func namespaceChangeFromPB(in *pb.NamespaceChange) NamespaceChange {
+	return NamespaceChange{
+		Namespace:    namespaceFromPB(in.GetNamespace()),
+		DoNotForward: in.GetDoNotForward(),
+		Deleted:      in.GetDeleted(),
+		Version:      in.GetVersion(),
+		Origin:       in.GetOrigin(),
+	}
+}
+
+func namespaceChangesToPB(changes []NamespaceChange) []*pb.NamespaceChange {
+	if len(changes) == 0 {
+		return nil
+	}
+	out := make([]*pb.NamespaceChange, len(changes))
+	for i, change := range changes {
+		out[i] = namespaceChangeToPB(change)
+	}
+	return out
+}
+
+func namespaceChangesFromPB(changes []*pb.NamespaceChange) []NamespaceChange {
+	if len(changes) == 0 {
+		return nil
+	}
+	out := make([]NamespaceChange, len(changes))
+	for i, change := range changes {
+		out[i] = namespaceChangeFromPB(change)
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ContextHeader carries the JSON encoded causal context for requests that use
+// the body for the value itself.
+const ContextHeader = "X-Causal-Context"
+
+const defaultContentType = "application/octet-stream"
+
+// serveRaw is the binary protocol. Values travel as raw request and response
+// bodies, the key is in the query and the causal context is in a header.
+func (s *Server) serveRaw(w http.ResponseWriter, r *http.Request) {
+	in := KV{
+		Namespace: r.URL.Query().Get("namespace"),
+		Key:       r.URL.Query().Get("key"),
+	}
+	if err := decodeContextHeader(r.Header, &in.Context); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var out KV
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		out, err = s.read(in)
+	case http.MethodPut, http.MethodPost:
+		s.lock.RLock()
+		ns, nserr := s.namespace(in.Namespace)
+		s.lock.RUnlock()
+		if nserr != nil {
+			writeRaw(w, r.Method, in, nserr)
+			return
+		}
+		if in.Value, err = readValue(r.Body, s.valueLimit(ns)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		in.ContentType = r.Header.Get("Content-Type")
+		out, err = s.write(r.Context(), in)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeRaw(w, r.Method, out, err)
+}
+
+// readValue reads at most limit+1 bytes of a value so that oversized values
+// are rejected by the write path without buffering all of them.
+func readValue(body io.Reader, limit int) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(body, int64(limit)+1))
+}
+
+func decodeContextHeader(h http.Header, ctx *VectorClock) error {
+	raw := h.Get(ContextHeader)
+	if raw == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw), ctx); err != nil {
+		return fmt.Errorf("invalid %s: %v", ContextHeader, err)
+	}
+	return nil
+}
+
+func writeRaw(w http.ResponseWriter, method string, out KV, err error) {
+	if out.Context != nil {
+		if ctx, err := json.Marshal(out.Context); err == nil {
+			w.Header().Set(ContextHeader, string(ctx))
+		}
+	}
+	if err != nil {
+		code := http.StatusInternalServerError
+		if withcode, ok := err.(HttpError); ok {
+			code = withcode.Code()
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if method != http.MethodGet {
+		if out.Pending {
+			w.WriteHeader(http.StatusAccepted)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	contentType := out.ContentType
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out.Value)
+}
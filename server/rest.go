@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The /v1/kv/{key} resource is the RESTful API. Like the binary protocol,
+// values travel as raw bodies and the causal context travels in
+// ContextHeader. The version of a key is its ETag, which PUT and DELETE
+// accept in If-Match.
+//
+// Keys are percent-encoded once: the key "a/b" is /v1/kv/a%2Fb. The mux
+// cleans the escaped path, so a key of "." or ".." must escape its dots.
+
+const kvPrefix = "/v1/kv/"
+
+// kvKey returns the key of a /v1/kv/ request. It decodes the escaped path
+// because the decoded one cannot tell "a/b" from "a%2Fb".
+func kvKey(r *http.Request) (string, error) {
+	return url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), kvPrefix))
+}
+
+func (s *Server) kvRequest(w http.ResponseWriter, r *http.Request) (KV, bool) {
+	key, err := kvKey(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return KV{}, false
+	}
+	in := KV{
+		Namespace: r.URL.Query().Get("namespace"),
+		Key:       key,
+		IfMatch:   unquoteETag(r.Header.Get("If-Match")),
+	}
+	if in.Key == "" {
+		http.Error(w, "missing key", http.StatusNotFound)
+		return KV{}, false
+	}
+	if err := decodeContextHeader(r.Header, &in.Context); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return KV{}, false
+	}
+	return in, true
+}
+
+func (s *Server) getKV(w http.ResponseWriter, r *http.Request) {
+	in, ok := s.kvRequest(w, r)
+	if !ok {
+		return
+	}
+	out, err := s.read(in)
+	if err == nil && out.Version != "" && unquoteETag(r.Header.Get("If-None-Match")) == out.Version {
+		writeVersionHeaders(w, out)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeKV(w, r.Method, out, err)
+}
+
+func (s *Server) putKV(w http.ResponseWriter, r *http.Request) {
+	in, ok := s.kvRequest(w, r)
+	if !ok {
+		return
+	}
+	s.lock.RLock()
+	ns, err := s.namespace(in.Namespace)
+	s.lock.RUnlock()
+	if err != nil {
+		writeKV(w, r.Method, in, err)
+		return
+	}
+	if in.Value, err = readValue(r.Body, s.valueLimit(ns)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	in.ContentType = r.Header.Get("Content-Type")
+	out, err := s.write(r.Context(), in)
+	writeKV(w, r.Method, out, err)
+}
+
+func (s *Server) deleteKV(w http.ResponseWriter, r *http.Request) {
+	in, ok := s.kvRequest(w, r)
+	if !ok {
+		return
+	}
+	out, err := s.delete(r.Context(), in)
+	writeKV(w, r.Method, out, err)
+}
+
+func writeKV(w http.ResponseWriter, method string, out KV, err error) {
+	writeVersionHeaders(w, out)
+	writeRaw(w, method, out, err)
+}
+
+func writeVersionHeaders(w http.ResponseWriter, out KV) {
+	if out.Version != "" {
+		w.Header().Set("ETag", `"`+out.Version+`"`)
+	}
+	if out.Context != nil {
+		if ctx, err := json.Marshal(out.Context); err == nil {
+			w.Header().Set(ContextHeader, string(ctx))
+		}
+	}
+}
+
+func unquoteETag(tag string) string {
+	tag = strings.TrimPrefix(tag, "W/")
+	return strings.Trim(tag, `"`)
+}
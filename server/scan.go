@@ -0,0 +1,77 @@
+package server
+
+import (
+	"sort"
+	"strings"
+)
+
+// defaultScanLimit is the most keys returned by a scan without a limit.
+const defaultScanLimit = 1000
+
+// Scan lists the live keys of a namespace that start with Prefix, in order.
+// At most Limit keys after After are returned, so that a scan can be paged
+// with the last key of each result.
+type Scan struct {
+	Namespace string      `json:"namespace,omitempty"`
+	Prefix    string      `json:"prefix,omitempty"`
+	After     string      `json:"after,omitempty"`
+	Limit     int         `json:"limit,omitempty"`
+	Context   VectorClock `json:"causal-context,omitempty"`
+}
+
+type ScanResult struct {
+	Results []KV        `json:"results"`
+	More    bool        `json:"more,omitempty"`
+	Context VectorClock `json:"causal-context,omitempty"`
+}
+
+func (s *Server) scan(in Scan) (ScanResult, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	s.Info("Scan", "ns", in.Namespace, "prefix", in.Prefix, "after", in.After, "ctx", in.Context)
+
+	if err := s.behind(in.Context); err != nil {
+		return ScanResult{}, err
+	}
+	if _, err := s.namespace(in.Namespace); err != nil {
+		return ScanResult{}, err
+	}
+	limit := in.Limit
+	if limit <= 0 {
+		limit = defaultScanLimit
+	}
+
+	var keys []string
+	for k := range s.latest {
+		if k.Namespace == in.Namespace && strings.HasPrefix(k.Key, in.Prefix) && k.Key > in.After {
+			keys = append(keys, k.Key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := ScanResult{
+		Results: []KV{},
+		Context: in.Context.Clone(),
+	}
+	for _, key := range keys {
+		col, ok := s.lookup(nskey{in.Namespace, key})
+		if !ok {
+			continue
+		}
+		if len(result.Results) == limit {
+			result.More = true
+			break
+		}
+		ctx := col.Clock.Context.Clone()
+		ctx.TakeMax(in.Context)
+		result.Results = append(result.Results, KV{
+			Namespace:   col.Namespace,
+			Key:         col.Key,
+			Value:       col.Value,
+			ContentType: col.ContentType,
+			Context:     ctx,
+		})
+		result.Context.TakeMax(col.Clock.Context)
+	}
+	return result, nil
+}
@@ -3,18 +3,30 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"maps"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/google/uuid"
+	"github.com/spencer-p/okayv/auth"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 )
 
 type HTTPClient interface {
@@ -24,37 +36,217 @@ type HTTPClient interface {
 type nothing struct{}
 
 type Column struct {
-	Key, Value string
-	Clock      CausalClock
-	Timestamp  time.Time
+	Namespace   string
+	Key         string
+	Value       []byte
+	ContentType string
+	Clock       CausalClock
+	Timestamp   time.Time
+	Expires     time.Time
+	Origin      string
+	// CRDT is the state of typed keys. Value holds its rendering.
+	CRDT *CRDT `json:",omitempty"`
+	// Deleted marks a tombstone. Tombstones replicate like writes but read as
+	// missing keys.
+	Deleted bool `json:",omitempty"`
+	// Trace is the W3C traceparent of the request that wrote the column, so
+	// that its replication can be followed in the same trace.
+	Trace string `json:",omitempty"`
+}
+
+// nskey identifies a key within its namespace.
+type nskey struct {
+	Namespace, Key string
+}
+
+func (c Column) nskey() nskey {
+	return nskey{c.Namespace, c.Key}
+}
+
+// clone copies the maps of c so that it can be used after the lock is
+// released.
+func (c Column) clone() Column {
+	c.Clock = c.Clock.Clone()
+	c.CRDT = c.CRDT.Clone()
+	return c
+}
+
+func cloneColumns(cols []Column) []Column {
+	out := make([]Column, len(cols))
+	for i, col := range cols {
+		out[i] = col.clone()
+	}
+	return out
+}
+
+func (c Column) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && now.After(c.Expires)
 }
 
 type CausalClock struct {
 	ID         uuid.UUID
 	Context    VectorClock
 	Replicated map[string]nothing
+	// Origin is Context on the column's origin replica. Replicas re-mark
+	// Context as they log the column, but Origin is the same on all of them.
+	Origin VectorClock `json:",omitempty"`
 }
 
+// DefaultMaxValueSize is the value size limit used when Opts.MaxValueSize is
+// zero.
+const DefaultMaxValueSize = 1 << 20
+
+// DefaultGossipBatch is the gossip batch size used when Opts.GossipBatch is
+// zero.
+const DefaultGossipBatch = 128
+
+// DefaultMaxLagRounds is the number of gossip rounds a server may miss before
+// it is not ready, when MaxLag is zero.
+const DefaultMaxLagRounds = 3
+
 type Opts struct {
 	*log.Logger
-	Name       string
-	Client     HTTPClient
-	GossipFreq time.Duration
+	Name         string
+	Client       HTTPClient
+	GossipFreq   time.Duration
+	MaxValueSize int
+	// GossipBatch is the most columns sent in one gossip request.
+	GossipBatch int
+	// GossipFanout is the number of peers gossiped with each round. The
+	// default is one.
+	GossipFanout int
+	// GossipMode is the direction columns travel in. The default is
+	// GossipPushPull.
+	GossipMode GossipMode
+	// PeerPolicy chooses the peers of each round. The default is PeerRandom.
+	PeerPolicy PeerPolicy
+	// Peers is the initial view, the URLs of every replica including this
+	// one. It is installed without forwarding it. The default is no view.
+	Peers []string
+	// Seeds are the URLs of replicas that RunBackground asks to join the
+	// cluster, which agrees on a view by merging them. Joins are sent over
+	// HTTP.
+	Seeds []string
+	// Advertise is the URL peers reach this node at. The default is
+	// http://Name.
+	Advertise string
+	// GossipCompression is the compression, CompressGzip or CompressZstd,
+	// offered to peers for gossip. The default is none.
+	GossipCompression string
+	// ChangeRetention is the most changes kept for /cdc. The oldest are
+	// dropped first. The default is DefaultChangeRetention.
+	ChangeRetention int
+	// MaxLag is how long the server may go without gossiping with any peer
+	// before /readyz fails. The default is DefaultMaxLagRounds rounds of
+	// GossipFreq.
+	MaxLag time.Duration
+	// PeerAuth requires a client certificate that names a host in the
+	// membership view on /gossip and on forwarded view changes, and on their
+	// RPCs. Without an ACL it is required to originate view changes too.
+	PeerAuth bool
+	// Authenticator authenticates requests. The default lets anyone in.
+	Authenticator auth.Authenticator
+	// ACL authorizes the principals that Authenticator returns. The default
+	// allows everything.
+	ACL *auth.ACL
+	// PeerSigner signs the requests sent to peers, whose principal must be
+	// an admin.
+	PeerSigner auth.Signer
+	// ViewKey signs the view changes this node originates, so that the
+	// replicas it forwards them to can verify them.
+	ViewKey ed25519.PrivateKey
+	// ViewKeys are the public keys of the nodes trusted to originate view
+	// changes, by name. With any, forwarded view changes must be signed.
+	ViewKeys map[string]ed25519.PublicKey
+	// AuditLog receives a JSON AuditEntry for each view change received.
+	AuditLog io.Writer
+	// GRPCDialOptions are used to connect to peers that use the gRPC
+	// transport. The default is an insecure connection.
+	GRPCDialOptions []grpc.DialOption
+	// TracerProvider records spans of requests, gossip and replicated
+	// writes. The default is the global provider.
+	TracerProvider trace.TracerProvider
 }
 
 type Server struct {
 	*Opts
 	peers []*url.URL
+	// nextPeer is the next peer of the round-robin policy.
+	nextPeer int
 
-	lock   sync.RWMutex
-	maxcc  VectorClock
-	events []Column
-	latest map[string]int
-	acked  map[string]int
-	byid   map[string]int
+	// connlock guards conns and encodings, the compression each peer has
+	// replied to gossip with.
+	connlock  sync.Mutex
+	conns     map[string]*grpc.ClientConn
+	encodings map[string]string
+	stats     gossipCounters
+	metrics   *metrics
+	tracer    trace.Tracer
+
+	lock    sync.RWMutex
+	maxcc   VectorClock
+	events  []Column
+	changes []Change
+	// firstChange is the offset of changes[0]; earlier changes were dropped.
+	firstChange int
+	latest      map[nskey]int
+	acked       map[string]int
+	byid        map[string]int
+	// tiebroken holds the IDs of columns that were superseded by a concurrent
+	// write winning a timestamp tie-break.
+	tiebroken map[string]nothing
+	// dropped holds remote columns that lost a tie-break on arrival.
+	dropped []Column
+	// siblings holds the indices of concurrent versions of keys in namespaces
+	// that keep siblings. Keys without siblings are absent.
+	siblings   map[nskey][]int
+	namespaces map[string]Namespace
+	// nsChanges holds the latest change of every namespace but the default,
+	// including deletions, to gossip to peers.
+	nsChanges map[string]NamespaceChange
+	// indexes holds the secondary indexes of each namespace by JSON path.
+	indexes map[string]map[string]*index
+	// lastView holds the issue time of the last view change from each
+	// origin, and auditLog the most recent view changes.
+	lastView map[string]time.Time
+	auditLog []AuditEntry
+	// lastGossip holds the time of the last successful gossip with each
+	// peer, and learned the greatest context each peer has shown us.
+	lastGossip map[string]time.Time
+	learned    map[string]VectorClock
+	started    time.Time
+	// view holds the replicas of the last view change, and is nil until
+	// one is installed.
+	view []string
+	// draining is set once Drain is called, after which writes are refused.
+	draining atomic.Bool
 }
 
 func NewServer(mux *http.ServeMux, opts Opts) *Server {
+	if opts.MaxValueSize == 0 {
+		opts.MaxValueSize = DefaultMaxValueSize
+	}
+	if opts.GossipBatch == 0 {
+		opts.GossipBatch = DefaultGossipBatch
+	}
+	if opts.ChangeRetention == 0 {
+		opts.ChangeRetention = DefaultChangeRetention
+	}
+	if opts.GossipFanout == 0 {
+		opts.GossipFanout = 1
+	}
+	if opts.GossipMode == "" {
+		opts.GossipMode = GossipPushPull
+	}
+	if opts.PeerPolicy == "" {
+		opts.PeerPolicy = PeerRandom
+	}
+	if opts.Advertise == "" {
+		opts.Advertise = "http://" + opts.Name
+	}
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
 	if opts.Logger == nil {
 		opts.Logger = log.NewWithOptions(os.Stderr, log.Options{
 			Prefix: fmt.Sprintf("[%s]", opts.Name),
@@ -63,20 +255,89 @@ func NewServer(mux *http.ServeMux, opts Opts) *Server {
 	srv := &Server{
 		Opts:   &opts,
 		maxcc:  make(VectorClock),
-		latest: make(map[string]int),
+		latest: make(map[nskey]int),
 		acked:  make(map[string]int),
 		byid:   make(map[string]int),
+
+		tiebroken: make(map[string]nothing),
+		siblings:  make(map[nskey][]int),
+		namespaces: map[string]Namespace{
+			DefaultNamespace: {Name: DefaultNamespace},
+		},
+		nsChanges: make(map[string]NamespaceChange),
+		indexes:   make(map[string]map[string]*index),
+		lastView:  make(map[string]time.Time),
+		conns:     make(map[string]*grpc.ClientConn),
+
+		encodings:  make(map[string]string),
+		lastGossip: make(map[string]time.Time),
+		learned:    make(map[string]VectorClock),
+		started:    time.Now(),
+	}
+	srv.metrics = newMetrics(srv)
+	srv.tracer = opts.TracerProvider.Tracer(tracerName)
+	for _, replica := range opts.Peers {
+		addr, err := url.Parse(replica)
+		if err != nil {
+			srv.Error("Initial peer has invalid URL", "url", replica)
+			continue
+		}
+		srv.view = append(srv.view, replica)
+		if addr.Host != opts.Name {
+			srv.peers = append(srv.peers, addr)
+		}
 	}
-	mux.HandleFunc("/read", JSONHandler(srv.read))
-	mux.HandleFunc("/write", JSONHandler(srv.write))
-	mux.HandleFunc("/view-change", JSONHandler(srv.viewChange))
-	mux.HandleFunc("/gossip", JSONHandler(srv.recvGossip))
+	// Every route is instrumented with its pattern as the label.
+	handle := func(pattern string, h http.HandlerFunc) {
+		mux.HandleFunc(pattern, srv.instrument(pattern, h))
+	}
+	handle("/read", authorized(srv, auth.OpRead, keyScope, srv.read))
+	handle("/write", authorizedCtx(srv, auth.OpWrite, keyScope, srv.write))
+	handle("/read-many", authorized(srv, auth.OpRead, keysScope, srv.readMany))
+	handle("/query", authorized(srv, auth.OpRead, queryScope, srv.query))
+	handle("/scan", authorized(srv, auth.OpRead, scanScope, srv.scan))
+	handle("/counter", authorizedCtx(srv, auth.OpWrite, crdtScope, srv.counter))
+	handle("/set", authorizedCtx(srv, auth.OpWrite, crdtScope, srv.set))
+	handle("/register", authorizedCtx(srv, auth.OpWrite, crdtScope, srv.register))
+	handle("/raw", srv.guard(rawScope, srv.serveRaw))
+	handle("/view-change", withConnState(authorizedAs(srv, auth.OpAdmin, nil, srv.viewChange)))
+	handle("/join", withConnState(authorizedAs(srv, auth.OpAdmin, nil, srv.join)))
+	handle("/audit", authorized(srv, auth.OpAdmin, nil, srv.auditEntries))
+	handle("/gossip", srv.peerOnly(srv.guard(adminScope, srv.gossipEncoding(JSONHandlerContext(srv.recvGossip)))))
+	handle("/gossip-stats", authorized(srv, "", nil, srv.gossipStats))
+	handle("/replication-lag", authorized(srv, "", nil, srv.replicationLag))
+	// Probes are not authenticated so that orchestrators can reach them.
+	handle("/healthz", JSONHandler(srv.healthz))
+	handle("/readyz", JSONHandler(srv.readyz))
+	handle("/status", authorized(srv, "", nil, srv.status))
+	handle("/debug/state", authorized(srv, auth.OpAdmin, nil, srv.debugState))
+	handle("/history", authorized(srv, auth.OpRead, keyScope, srv.history))
+	handle("/namespaces", byMethod(map[string]http.HandlerFunc{
+		http.MethodGet:    authorized(srv, "", nil, srv.listNamespaces),
+		http.MethodPut:    authorizedCtx(srv, auth.OpAdmin, nil, srv.putNamespace),
+		http.MethodDelete: authorizedCtx(srv, auth.OpAdmin, nil, srv.deleteNamespace),
+	}))
+	handle("/cdc", srv.guard(adminScope, srv.serveChanges))
+	handle("/v1/kv/", byMethod(map[string]http.HandlerFunc{
+		http.MethodGet:    srv.guard(restScope, srv.getKV),
+		http.MethodPut:    srv.guard(restScope, srv.putKV),
+		http.MethodDelete: srv.guard(restScope, srv.deleteKV),
+	}))
+	handle("/metrics", srv.guard(authnScope, srv.metricsHandler().ServeHTTP))
 	srv.Infof("Starting")
 	return srv
 }
 
 func (s *Server) RunBackground(ctx context.Context) {
-	tick := time.NewTicker(s.GossipFreq)
+	if len(s.Seeds) > 0 {
+		go func() {
+			if err := s.Join(ctx); err != nil {
+				s.Warn("Failed to join", "err", err)
+			}
+		}()
+	}
+	freq := s.gossipFreq()
+	tick := time.NewTicker(freq)
 	defer tick.Stop()
 	for {
 		select {
@@ -84,36 +345,38 @@ func (s *Server) RunBackground(ctx context.Context) {
 			return
 		case <-tick.C:
 			s.Gossip()
+			// The frequency may have been tuned since the last round.
+			if next := s.gossipFreq(); next != freq {
+				freq = next
+				tick.Reset(freq)
+			}
 		}
 	}
 }
 
-func (s *Server) Gossip() {
-	if len(s.peers) == 0 {
-		return
-	}
-	i := rand.Intn(len(s.peers))
-	err := s.gossipOnce(s.peers[i])
-	if err != nil {
-		s.Warn("Failed to gossip", "dst", s.peers[i], "err", err)
-	}
-}
-
-type withError struct {
-	any   `json:,inline`
-	Error string `json:"error"`
+func JSONHandler[In any, Out any](h func(In) (Out, error)) func(http.ResponseWriter, *http.Request) {
+	return JSONHandlerContext(func(_ context.Context, in In) (Out, error) {
+		return h(in)
+	})
 }
 
-func JSONHandler[In any, Out any](h func(In) (Out, error)) func(http.ResponseWriter, *http.Request) {
+// JSONHandlerContext is JSONHandler for handlers that need the context of the
+// request. The context continues the trace in the request's headers.
+func JSONHandlerContext[In any, Out any](h func(context.Context, In) (Out, error)) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			ctx = propagator.Extract(ctx, propagation.HeaderCarrier(r.Header))
+		}
 		var in In
-		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		// An empty body decodes to the zero value.
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
 			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		out, err := h(in)
+		out, err := h(ctx, in)
 		if err != nil {
 			code := http.StatusInternalServerError
 			if withcode, ok := err.(HttpError); ok {
@@ -121,22 +384,98 @@ func JSONHandler[In any, Out any](h func(In) (Out, error)) func(http.ResponseWri
 			}
 
 			w.WriteHeader(code)
-			_ = json.NewEncoder(w).Encode(withError{
-				any:   out,
-				Error: err.Error(),
-			})
+			_ = json.NewEncoder(w).Encode(withError(out, err))
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
+		code := http.StatusOK
+		if s, ok := any(out).(statuser); ok {
+			code = s.status()
+		}
+		w.WriteHeader(code)
 		_ = json.NewEncoder(w).Encode(&out)
 	}
 }
 
+// statuser is implemented by results that choose their own success status.
+type statuser interface {
+	status() int
+}
+
+// withError adds an error field to the JSON encoding of out.
+func withError(out any, err error) map[string]any {
+	fields := map[string]any{}
+	if buf, merr := json.Marshal(out); merr == nil {
+		_ = json.Unmarshal(buf, &fields)
+	}
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	fields["error"] = err.Error()
+	return fields
+}
+
+// byMethod dispatches requests by method and rejects any other method.
+func byMethod(handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	var allowed []string
+	for method := range handlers {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return func(w http.ResponseWriter, r *http.Request) {
+		h, ok := handlers[r.Method]
+		if !ok {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	}
+}
+
 type KV struct {
-	Key     string      `json:"key"`
-	Value   string      `json:"value"`
-	Context VectorClock `json:"causal-context,omitempty"`
+	Namespace   string      `json:"namespace,omitempty"`
+	Key         string      `json:"key"`
+	Value       []byte      `json:"value"`
+	ContentType string      `json:"content-type,omitempty"`
+	Context     VectorClock `json:"causal-context,omitempty"`
+	// At requests a point-in-time read of the newest version whose clock on
+	// its origin is at most At. At may come from any replica.
+	At VectorClock `json:"at,omitempty"`
+	// TTL overrides the namespace's default time to live for a write.
+	TTL Duration `json:"ttl,omitempty"`
+	// Siblings holds every concurrent value of the key, including Value, in
+	// namespaces that keep siblings.
+	Siblings [][]byte `json:"siblings,omitempty"`
+	// Version identifies the current version of the key.
+	Version string `json:"version,omitempty"`
+	// IfMatch makes a write or delete fail with 412 unless the current
+	// version of the key is IfMatch. "*" matches any existing version.
+	IfMatch string `json:"if-match,omitempty"`
+	// Replicas is the number of replicas known to hold a write in a
+	// namespace with a replication factor, and Pending is set when they are
+	// fewer than the factor. Pending writes are answered with 202.
+	Replicas int  `json:"replicas,omitempty"`
+	Pending  bool `json:"pending,omitempty"`
+}
+
+func (kv KV) status() int {
+	if kv.Pending {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// behind returns a 503 error, and counts it, if the server has not yet seen
+// all the events of any of ctxs.
+func (s *Server) behind(ctxs ...VectorClock) error {
+	for _, ctx := range ctxs {
+		if s.maxcc.Behind(ctx) {
+			s.metrics.behind.Inc()
+			return newerr(http.StatusServiceUnavailable, fmt.Errorf("cannot service client"))
+		}
+	}
+	return nil
 }
 
 func (s *Server) read(in KV) (KV, error) {
@@ -144,82 +483,296 @@ func (s *Server) read(in KV) (KV, error) {
 	defer s.lock.RUnlock()
 	s.Info("Read", "key", in.Key, "ctx", in.Context)
 
-	if s.maxcc.Behind(in.Context) {
-		return KV{}, newerr(http.StatusServiceUnavailable, fmt.Errorf("cannot service client"))
+	if err := s.behind(in.Context, in.At); err != nil {
+		return KV{}, err
+	}
+	if _, err := s.namespace(in.Namespace); err != nil {
+		return in, err
 	}
 
-	col, ok := s.lookup(in.Key)
+	k := nskey{in.Namespace, in.Key}
+	var col Column
+	var ok bool
+	if in.At != nil {
+		col, ok = s.lookupAt(k, in.At)
+	} else {
+		col, ok = s.lookup(k)
+	}
 	if !ok {
 		return in, newerr(http.StatusNotFound, fmt.Errorf("read %s: does not exist", in.Key))
 	}
 	newctx := col.Clock.Context.Clone()
 	newctx.TakeMax(in.Context)
-	return KV{
-		Key:     col.Key,
-		Value:   col.Value,
-		Context: newctx,
-	}, nil
+	out := KV{
+		Namespace:   col.Namespace,
+		Key:         col.Key,
+		Value:       col.Value,
+		ContentType: col.ContentType,
+		Context:     newctx,
+		Version:     col.Clock.ID.String(),
+	}
+	if in.At == nil {
+		for _, idx := range s.siblings[k] {
+			sibling := s.events[idx]
+			if sibling.Deleted {
+				continue
+			}
+			out.Siblings = append(out.Siblings, sibling.Value)
+			out.Context.TakeMax(sibling.Clock.Context)
+		}
+	}
+	return out, nil
+}
+
+type ReadMany struct {
+	Namespace string      `json:"namespace,omitempty"`
+	Keys      []string    `json:"keys"`
+	Context   VectorClock `json:"causal-context,omitempty"`
+	At        VectorClock `json:"at,omitempty"`
 }
 
-func (s *Server) write(in KV) (KV, error) {
-	s.Info("Write", "key", in.Key, "val", in.Value, "ctx", in.Context)
-	return s.update(in, true)
+type Snapshot struct {
+	Values  map[string][]byte `json:"values"`
+	Context VectorClock       `json:"causal-context,omitempty"`
 }
 
-func (s *Server) update(in KV, allowRewrite bool) (KV, error) {
+// readMany reads several keys from one causal cut of this replica. Keys that
+// do not exist at the cut are left out of the result.
+func (s *Server) readMany(in ReadMany) (Snapshot, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	s.Info("Read many", "keys", in.Keys, "ctx", in.Context)
+
+	if err := s.behind(in.Context, in.At); err != nil {
+		return Snapshot{}, err
+	}
+	if _, err := s.namespace(in.Namespace); err != nil {
+		return Snapshot{}, err
+	}
+
+	result := Snapshot{
+		Values:  make(map[string][]byte, len(in.Keys)),
+		Context: in.Context.Clone(),
+	}
+	for _, key := range in.Keys {
+		var col Column
+		var ok bool
+		k := nskey{in.Namespace, key}
+		if in.At != nil {
+			col, ok = s.lookupAt(k, in.At)
+		} else {
+			col, ok = s.lookup(k)
+		}
+		if !ok {
+			continue
+		}
+		result.Values[key] = col.Value
+		result.Context.TakeMax(col.Clock.Context)
+	}
+	return result, nil
+}
+
+func (s *Server) write(ctx context.Context, in KV) (KV, error) {
+	s.Info("Write", "key", in.Key, "val", string(in.Value), "ctx", in.Context)
+	if err := s.acceptingWrites(); err != nil {
+		return KV{}, err
+	}
+	s.lock.RLock()
+	ns, err := s.namespace(in.Namespace)
+	s.lock.RUnlock()
+	if err != nil {
+		return KV{}, err
+	}
+	if limit := s.valueLimit(ns); len(in.Value) > limit {
+		return KV{}, newerr(http.StatusRequestEntityTooLarge, fmt.Errorf("value of %d bytes exceeds limit of %d", len(in.Value), limit))
+	}
+	out, err := s.update(ctx, in, true)
+	if err != nil || ns.ReplicationFactor <= 1 {
+		return out, err
+	}
+	out.Replicas = s.replicate(ctx, out, ns.ReplicationFactor)
+	out.Pending = out.Replicas < ns.ReplicationFactor
+	return out, nil
+}
+
+func (s *Server) update(ctx context.Context, in KV, allowRewrite bool) (KV, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	if s.maxcc.Behind(in.Context) {
-		return KV{}, newerr(http.StatusServiceUnavailable, fmt.Errorf("cannot service client"))
+	if err := s.behind(in.Context); err != nil {
+		return KV{}, err
+	}
+	ns, err := s.namespace(in.Namespace)
+	if err != nil {
+		return KV{}, err
 	}
 
-	existing, alreadyExists := s.lookup(in.Key)
+	k := nskey{in.Namespace, in.Key}
+	existing, alreadyExists := s.lookup(k)
 	if alreadyExists && !allowRewrite {
 		return KV{
-			Key:     existing.Key,
-			Value:   existing.Value,
-			Context: existing.Clock.Context,
+			Namespace:   existing.Namespace,
+			Key:         existing.Key,
+			Value:       existing.Value,
+			ContentType: existing.ContentType,
+			Context:     existing.Clock.Context,
 		}, newerr(http.StatusBadRequest, fmt.Errorf("already exists"))
 	}
 
+	if err := precondition(in.IfMatch, existing, alreadyExists); err != nil {
+		return KV{}, err
+	}
+	if alreadyExists && existing.CRDT != nil {
+		return KV{}, newerr(http.StatusConflict, fmt.Errorf("%s is a %s", in.Key, existing.CRDT.Type))
+	}
+
 	// If the client is writing something we already have, ack w/o doing
 	// anything but advance their clock if needed.
-	if alreadyExists && in.Key == existing.Key && in.Value == existing.Value {
+	if alreadyExists && len(s.siblings[k]) == 0 &&
+		bytes.Equal(in.Value, existing.Value) && in.ContentType == existing.ContentType {
 		in.Context.TakeMax(existing.Clock.Context)
+		in.Version = existing.Clock.ID.String()
 		return in, nil
 	}
 
-	s.maxcc.TakeMax(in.Context)
-	s.maxcc.Mark(s.Name)
-	newclock := CausalClock{
-		ID:         uuid.New(),
-		Context:    s.maxcc.Clone(),
-		Replicated: map[string]nothing{s.Name: {}},
+	ttl := in.TTL
+	if ttl == 0 {
+		ttl = ns.TTL
+	}
+	col := s.appendLocal(Column{
+		Namespace:   in.Namespace,
+		Key:         in.Key,
+		Value:       in.Value,
+		ContentType: in.ContentType,
+		Trace:       traceparent(ctx),
+	}, in.Context, ttl)
+	return KV{
+		Namespace:   in.Namespace,
+		Key:         in.Key,
+		Value:       in.Value,
+		ContentType: in.ContentType,
+		Context:     col.Clock.Context,
+		Version:     col.Clock.ID.String(),
+	}, nil
+}
+
+func (s *Server) delete(ctx context.Context, in KV) (KV, error) {
+	if err := s.acceptingWrites(); err != nil {
+		return KV{}, err
+	}
+	s.lock.RLock()
+	ns, err := s.namespace(in.Namespace)
+	s.lock.RUnlock()
+	if err != nil {
+		return KV{}, err
+	}
+	out, err := s.remove(ctx, in)
+	if err != nil || ns.ReplicationFactor <= 1 {
+		return out, err
 	}
+	out.Replicas = s.replicate(ctx, out, ns.ReplicationFactor)
+	out.Pending = out.Replicas < ns.ReplicationFactor
+	return out, nil
+}
+
+// remove replaces the value of a key with a tombstone.
+func (s *Server) remove(ctx context.Context, in KV) (KV, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Info("Delete", "key", in.Key, "ctx", in.Context)
 
-	s.events = append(s.events, Column{
+	if err := s.behind(in.Context); err != nil {
+		return KV{}, err
+	}
+	if _, err := s.namespace(in.Namespace); err != nil {
+		return KV{}, err
+	}
+	existing, ok := s.lookup(nskey{in.Namespace, in.Key})
+	if err := precondition(in.IfMatch, existing, ok); err != nil {
+		return KV{}, err
+	}
+	if !ok {
+		return KV{}, newerr(http.StatusNotFound, fmt.Errorf("delete %s: does not exist", in.Key))
+	}
+	col := s.appendLocal(Column{
+		Namespace: in.Namespace,
 		Key:       in.Key,
-		Value:     in.Value,
-		Clock:     newclock,
-		Timestamp: time.Now(),
-	})
-	s.latest[in.Key] = len(s.events) - 1
-	s.byid[newclock.ID.String()] = len(s.events) - 1
+		Deleted:   true,
+		Trace:     traceparent(ctx),
+	}, in.Context, 0)
 	return KV{
-		Key:     in.Key,
-		Value:   in.Value,
-		Context: newclock.Context,
+		Namespace: in.Namespace,
+		Key:       in.Key,
+		Context:   col.Clock.Context,
+		Version:   col.Clock.ID.String(),
 	}, nil
 }
 
+// precondition checks an If-Match version against the current version of a
+// key.
+func precondition(ifMatch string, existing Column, exists bool) error {
+	if ifMatch == "" {
+		return nil
+	}
+	if !exists {
+		return newerr(http.StatusPreconditionFailed, fmt.Errorf("key does not exist"))
+	}
+	if ifMatch != "*" && ifMatch != existing.Clock.ID.String() {
+		return newerr(http.StatusPreconditionFailed, fmt.Errorf("version %s is not current", ifMatch))
+	}
+	return nil
+}
+
+// appendLocal records col as a new write on this replica that happens after
+// ctx.
+// appendLocal assumes the write lock is held.
+func (s *Server) appendLocal(col Column, ctx VectorClock, ttl Duration) Column {
+	s.maxcc.TakeMax(ctx)
+	s.maxcc.Mark(s.Name)
+	col.Clock = CausalClock{
+		ID:         uuid.New(),
+		Context:    s.maxcc.Clone(),
+		Replicated: map[string]nothing{s.Name: {}},
+		Origin:     s.maxcc.Clone(),
+	}
+	col.Timestamp = time.Now()
+	col.Origin = s.Name
+	if ttl > 0 {
+		col.Expires = col.Timestamp.Add(time.Duration(ttl))
+	}
+	k := col.nskey()
+	s.events = append(s.events, col)
+	s.setLatest(k, len(s.events)-1)
+	s.byid[col.Clock.ID.String()] = len(s.events) - 1
+	s.logChange(ChangeWrite, "", len(s.events)-1, col)
+	return col
+}
+
+// ViewChange replaces the peers of the replicas. The replica it is sent to
+// first is its origin, which signs it before forwarding it.
 type ViewChange struct {
-	Replicas     []string `json:"replicas"`
-	DoNotForward bool     `json:"donotforward,omitempty"`
+	Replicas     []string  `json:"replicas"`
+	DoNotForward bool      `json:"donotforward,omitempty"`
+	Origin       string    `json:"origin,omitempty"`
+	Principal    string    `json:"principal,omitempty"`
+	Issued       time.Time `json:"issued"`
+	Signature    []byte    `json:"signature,omitempty"`
 }
 
-func (s *Server) viewChange(in ViewChange) (nothing, error) {
+// viewChange applies a view change sent by principal, and forwards it when
+// this node is its origin. Each change is audited, applied or not.
+func (s *Server) viewChange(ctx context.Context, principal string, in ViewChange) (_ nothing, err error) {
 	s.Info("Receiving view change")
+	if !in.DoNotForward {
+		in = s.originate(principal, in)
+	}
+	defer func() { s.audit(principal, in, err) }()
+	if err := s.authorizeViewChange(ctx, in); err != nil {
+		return nothing{}, err
+	}
+	if err := s.verifyViewChange(in); err != nil {
+		return nothing{}, err
+	}
 	var next []*url.URL
 	for _, replica := range in.Replicas {
 		addr, err := url.Parse(replica)
@@ -234,98 +787,258 @@ func (s *Server) viewChange(in ViewChange) (nothing, error) {
 		next = append(next, addr)
 		if !in.DoNotForward {
 			s.Info("Forwarding view change", "dst", replica)
-			if err := s.forwardViewChange(in, replica); err != nil {
+			if err := s.forwardViewChange(ctx, in, addr); err != nil {
 				return nothing{}, err
 			}
 		}
 	}
-	s.peers = next // TODO: Data race/hazard here.
+	s.lock.Lock()
+	s.setView(in.Replicas, next)
+	s.lock.Unlock()
 	// TODO: Maybe wait for replication or manually start replication.
 	return nothing{}, nil
 }
 
-func (s *Server) forwardViewChange(in ViewChange, addr string) error {
-	var body bytes.Buffer
-	if err := json.NewEncoder(&body).Encode(ViewChange{
+// setView installs the replicas of a view and its peers, the replicas other
+// than this one. It assumes the write lock is held.
+func (s *Server) setView(replicas []string, peers []*url.URL) {
+	s.peers = peers
+	s.view = append([]string{}, replicas...)
+	if s.nextPeer >= len(peers) {
+		s.nextPeer = 0
+	}
+}
+
+func (s *Server) forwardViewChange(ctx context.Context, in ViewChange, addr *url.URL) error {
+	fwd := ViewChange{
 		Replicas:     in.Replicas[:],
 		DoNotForward: true,
-	}); err != nil {
+		Origin:       in.Origin,
+		Principal:    in.Principal,
+		Issued:       in.Issued,
+		Signature:    in.Signature,
+	}
+	if addr.Scheme == GRPCScheme {
+		return s.viewChangeGRPC(ctx, addr, fwd)
+	}
+	return s.forward(ctx, http.MethodPut, addr.String(), "/view-change", fwd, nil)
+}
+
+// forward sends an admin request on to a peer, which must accept it. The reply
+// is decoded into out unless it is nil.
+func (s *Server) forward(ctx context.Context, method, addr, path string, in, out any) error {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(in); err != nil {
 		return err
 	}
 
-	httpreq, err := http.NewRequest(http.MethodPut, addr+"/view-change", &body)
+	httpreq, err := http.NewRequestWithContext(ctx, method, addr+path, bytes.NewReader(body.Bytes()))
 	if err != nil {
 		return err
 	}
 	httpreq.Header.Set("User-Agent", s.Name)
+	injectTrace(ctx, httpreq)
+	if err := s.signPeer(httpreq, body.Bytes()); err != nil {
+		return err
+	}
 	resp, err := s.Client.Do(httpreq)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return newerr(http.StatusInternalServerError, fmt.Errorf("forward view change to %s failed: %d", addr, resp.StatusCode))
+		return newerr(http.StatusInternalServerError, fmt.Errorf("forward %s to %s failed: %d", path, addr, resp.StatusCode))
 	}
-	return nil
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
 }
 
+// gossipOnce replicates with dst in the server's gossip mode.
 func (s *Server) gossipOnce(dst *url.URL) error {
-	s.lock.Lock()
-	defer s.lock.Unlock()
-
-	replicate := s.unreplicated(dst.Host)
-	if len(replicate) == 0 {
-		return nil
+	ctx, span := s.tracer.Start(context.Background(), "gossip",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("okayv.replica", s.Name),
+			attribute.String("okayv.peer", dst.Host),
+			attribute.String("okayv.mode", string(s.GossipMode)),
+		))
+	defer span.End()
+	err := s.gossipMode(ctx, dst, s.GossipMode)
+	result := "ok"
+	if err != nil {
+		result = "error"
+		span.SetStatus(codes.Error, err.Error())
 	}
-	req := Gossip{
-		Host:    s.Name,
-		Columns: replicate,
+	s.metrics.gossipRounds.WithLabelValues(dst.Host, result).Inc()
+	if err == nil {
+		s.lock.Lock()
+		s.lastGossip[dst.Host] = time.Now()
+		s.lock.Unlock()
 	}
+	return err
+}
 
-	// Push to other server.
-	s.Info("Send gossip", "dst", dst.Host, "cols", len(req.Columns))
-	var resp GossipResponse
-	if err := s.JSONRequest(http.MethodPut, dst.String()+"/gossip", req, &resp); err != nil {
-		return err
+// gossipMode replicates with dst in batches of at most GossipBatch columns.
+// The lock is held to pick a batch and to play back the reply, but never
+// across network I/O.
+func (s *Server) gossipMode(ctx context.Context, dst *url.URL, mode GossipMode) error {
+	for {
+		var batch []Column
+		var more bool
+		// Namespaces are few, so all of them are sent every round.
+		s.lock.Lock()
+		namespaces := s.namespaceChanges()
+		if mode != GossipPull {
+			batch, more = s.unreplicated(dst.Host, s.GossipBatch)
+		}
+		s.lock.Unlock()
+		if len(batch) == 0 && len(namespaces) == 0 && mode == GossipPush {
+			return nil
+		}
+
+		// Push to other server. An empty batch only pulls.
+		s.Info("Send gossip", "dst", dst.Host, "mode", mode, "cols", len(batch), "more", more)
+		resp, err := s.exchange(ctx, dst, Gossip{
+			Host:       s.Name,
+			Columns:    batch,
+			PushOnly:   mode == GossipPush,
+			Namespaces: namespaces,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Play back the columns and acks we got back, then ack the columns
+		// to the dst. The columns go first since the acks may count events
+		// logged after them, and the namespaces before both.
+		s.lock.Lock()
+		s.playNamespaces(resp.Namespaces)
+		updated := s.playLog(ctx, dst.Host, resp.Columns)
+		acks := s.acksOf(updated)
+		s.playAcks(dst.Host, resp.Acks)
+		accepted := s.countReplicated(batch, dst.Host)
+		s.lock.Unlock()
+		if len(acks) > 0 {
+			s.Info("Acking gossip", "dst", dst.Host, "acks", len(acks))
+			_, err := s.exchange(ctx, dst, Gossip{
+				Host:     s.Name,
+				Acks:     acks,
+				PushOnly: true,
+			})
+			if err != nil {
+				return err
+			}
+			s.lock.Lock()
+			s.learnAcked(dst.Host, updated)
+			s.lock.Unlock()
+		}
+
+		// Only continue while the batches make progress. Otherwise the
+		// receiver is missing events from elsewhere and would reject the
+		// rest too.
+		if mode == GossipPull {
+			if len(resp.Columns) == 0 || len(acks) == 0 {
+				return nil
+			}
+		} else if !more || accepted == 0 {
+			return nil
+		}
 	}
+}
 
-	// Play back the columns we got back, then ack them to the dst.
-	// At this point resp is expected to be empty.
-	req.Columns = s.playLog(dst.Host, resp.Columns)
-	if len(req.Columns) == 0 {
-		// End gossip if there is nothing to ack.
-		return nil
+// countReplicated counts the columns of batch that are known to be
+// replicated to remote.
+// countReplicated assumes the read lock is held.
+func (s *Server) countReplicated(batch []Column, remote string) int {
+	n := 0
+	for _, col := range batch {
+		if existing, ok := s.lookupID(col.Clock.ID); ok {
+			if _, acked := existing.Clock.Replicated[remote]; acked {
+				n++
+			}
+		}
 	}
-	s.Info("Acking gossip", "dst", dst.Host, "acks", len(req.Columns))
-	if err := s.JSONRequest(http.MethodPut, dst.String()+"/gossip", req, &resp); err != nil {
-		return err
+	return n
+}
+
+// exchange sends gossip to a peer over its transport.
+func (s *Server) exchange(ctx context.Context, dst *url.URL, req Gossip) (GossipResponse, error) {
+	req.Columns = encodeContexts(req.Columns)
+	req.Delta = true
+	var resp GossipResponse
+	var err error
+	if dst.Scheme == GRPCScheme {
+		resp, err = s.gossipGRPC(ctx, dst, req)
+	} else {
+		resp, err = s.gossipHTTP(ctx, dst, req)
 	}
-	if len(resp.Columns) > 0 {
-		s.Warn("Expected no more gossip, dropping", "dst", dst.Host, "cols", len(resp.Columns))
+	if err == nil && resp.Delta {
+		resp.Columns = decodeContexts(resp.Columns)
 	}
-
-	return nil
+	s.metrics.columnsSent.Add(float64(len(req.Columns)))
+	s.metrics.columnsReceived.Add(float64(len(resp.Columns)))
+	trace.SpanFromContext(ctx).AddEvent("exchange", trace.WithAttributes(
+		attribute.Int("okayv.sent", len(req.Columns)),
+		attribute.Int("okayv.acks", len(req.Acks)),
+		attribute.Int("okayv.received", len(resp.Columns)),
+	))
+	return resp, err
 }
 
 type Gossip struct {
 	Host    string
 	Columns []Column
+	// Acks acknowledges columns the receiver sent earlier.
+	Acks []Ack `json:",omitempty"`
+	// PushOnly asks the receiver to reply with acks only, and not with its
+	// own unreplicated columns.
+	PushOnly bool `json:",omitempty"`
+	// Delta is set when the contexts of Columns are delta encoded.
+	Delta bool `json:",omitempty"`
+	// Namespaces holds the latest change of every namespace the sender knows.
+	Namespaces []NamespaceChange `json:",omitempty"`
 }
 
 type GossipResponse struct {
-	Columns []Column
+	Columns    []Column
+	Acks       []Ack             `json:",omitempty"`
+	Delta      bool              `json:",omitempty"`
+	Namespaces []NamespaceChange `json:",omitempty"`
 }
 
-func (s *Server) recvGossip(in Gossip) (GossipResponse, error) {
+func (s *Server) recvGossip(ctx context.Context, in Gossip) (GossipResponse, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	s.Info("Receiving gossip", "src", in.Host, "cols", len(in.Columns))
+	s.Info("Receiving gossip", "src", in.Host, "cols", len(in.Columns), "acks", len(in.Acks))
 
-	updated := s.playLog(in.Host, in.Columns)
-	replicate := s.unreplicated(in.Host)
+	if in.Delta {
+		in.Columns = decodeContexts(in.Columns)
+	}
+	// Namespaces go first so that columns of deleted ones are not restored.
+	s.playNamespaces(in.Namespaces)
+	updated := s.playLog(ctx, in.Host, in.Columns)
+	s.playAcks(in.Host, in.Acks)
+	s.lastGossip[in.Host] = time.Now()
+	// The reply carries the acks, so the sender will have them unless it
+	// fails, in which case it gossips again.
+	s.learnAcked(in.Host, updated)
+	var replicate []Column
+	var namespaces []NamespaceChange
+	if !in.PushOnly {
+		replicate, _ = s.unreplicated(in.Host, s.GossipBatch)
+		namespaces = s.namespaceChanges()
+	}
 	s.Info("Gossip reply", "cols", len(replicate), "acks", len(updated))
+	s.metrics.columnsReceived.Add(float64(len(in.Columns)))
+	s.metrics.columnsSent.Add(float64(len(replicate)))
 	resp := GossipResponse{
-		Columns: append(replicate, updated...),
+		Columns:    encodeContexts(replicate),
+		Acks:       s.acksOf(updated),
+		Delta:      true,
+		Namespaces: namespaces,
 	}
 
 	return resp, nil
@@ -334,17 +1047,13 @@ func (s *Server) recvGossip(in Gossip) (GossipResponse, error) {
 // playLog plays a log of columns onto history and returns a list of updated
 // columns. Not all columns may be played.
 // playLog assumes the write lock is held.
-func (s *Server) playLog(host string, log []Column) (updated []Column) {
+func (s *Server) playLog(ctx context.Context, host string, log []Column) (updated []Column) {
 	for _, col := range log {
+		s.learn(host, col.Clock.Context)
 		// If the event is already recorded, only update the replication data.
-		if existing, ok := s.lookupID(col.Clock.ID); ok {
-			if !existing.Clock.Equal(col.Clock) {
-				s.Info("Updating replication metadata", "key", col.Key)
-				existing.Clock.Merge(col.Clock)
-				s.maxcc.TakeMax(existing.Clock.Context)
+		if _, ok := s.lookupID(col.Clock.ID); ok {
+			if existing, ok := s.ack(host, ackOf(col, host)); ok {
 				updated = append(updated, existing)
-			} else {
-				s.Info("Skipping prev. acked", "key", col.Key)
 			}
 			continue
 		}
@@ -353,34 +1062,58 @@ func (s *Server) playLog(host string, log []Column) (updated []Column) {
 		concurrent := s.maxcc.Concurrent(col.Clock.Context)
 		happensafter := s.maxcc.AheadOneN(col.Clock.Context, len(col.Clock.Replicated))
 		if !(concurrent || happensafter) {
-			s.Warn("Cannot ack further", "key", col.Key, "val", col.Value, "us", s.maxcc, "them", col.Clock.Context, "repl", col.Clock.Replicated)
+			s.metrics.ackStalls.Inc()
+			trace.SpanFromContext(ctx).AddEvent("Cannot ack further", trace.WithAttributes(
+				attribute.String("okayv.key", col.Key),
+				attribute.String("okayv.trace", col.Trace),
+			))
+			s.Warn("Cannot ack further", "key", col.Key, "val", string(col.Value), "us", s.maxcc, "them", col.Clock.Context, "repl", col.Clock.Replicated)
 			return updated
 		}
 
 		// If there is a merge conflict on concurrent writes, choose a winner.
-		// If the local copy wins, stop processing.
-		if concurrent {
-			if existing, exists := s.lookup(col.Key); exists {
+		// If the local copy wins, stop processing. Namespaces that keep
+		// siblings skip the tie-break and keep both.
+		k := col.nskey()
+		sibling := false
+		existing, exists := s.version(k)
+		if exists && existing.CRDT != nil && col.CRDT != nil && existing.CRDT.Type == col.CRDT.Type {
+			// Typed keys merge instead of choosing a winner. The merged state
+			// includes the local one, so it supersedes it.
+			s.Info("Merging typed write", "key", col.Key, "type", col.CRDT.Type)
+			merged := existing.CRDT.Clone()
+			merged.Merge(col.CRDT)
+			col.CRDT = merged
+			col.Value, col.ContentType = merged.render()
+		} else if concurrent {
+			if exists && s.namespaces[col.Namespace].Policy == PolicySiblings {
+				s.Info("Keeping concurrent write as sibling", "key", col.Key, "val", string(col.Value))
+				sibling = true
+			} else if exists {
 				s.Warn("Breaking tie by timestamp",
 					"key", col.Key,
-					"localval", existing.Value,
-					"remoteval", col.Value,
+					"localval", string(existing.Value),
+					"remoteval", string(col.Value),
 					"localtime", existing.Timestamp,
 					"remotetime", col.Timestamp)
 				if existing.Timestamp.After(col.Timestamp) {
-					s.Warn("Dropping remote write", "key", col.Key, "val", col.Value)
+					s.Warn("Dropping remote write", "key", col.Key, "val", string(col.Value))
 					// Instead of short-circuiting, we take the event count but
 					// drop the column, simulating if the event had happened and
 					// was overwritten.
 					s.maxcc.TakeMax(col.Clock.Context)
+					s.dropped = append(s.dropped, col)
+					s.metrics.tiebreakDrops.Inc()
+					s.logChange(ChangeDrop, host, -1, col)
 					continue
 				}
+				s.tiebroken[existing.Clock.ID.String()] = nothing{}
 			}
 		}
 
 		// The event was concurrent w.r.t us or it's one event after, we can
 		// accept it.
-		s.Info("Logging event", "key", col.Key, "val", col.Value, "ctx", col.Clock.Context, "repl", col.Clock.Replicated)
+		s.Info("Logging event", "key", col.Key, "val", string(col.Value), "ctx", col.Clock.Context, "repl", col.Clock.Replicated)
 
 		// Build the new clock, marking it as an event ourselves.
 		s.maxcc.TakeMax(col.Clock.Context)
@@ -388,23 +1121,152 @@ func (s *Server) playLog(host string, log []Column) (updated []Column) {
 		col.Clock.Context = s.maxcc.Clone()
 		col.Clock.Replicated[s.Name] = nothing{}
 
-		// Append it to history.
+		// Append it to history. Columns of deleted namespaces are kept in the
+		// history but never become current.
 		s.events = append(s.events, col)
-		s.latest[col.Key] = len(s.events) - 1
+		if s.deleted(col.Namespace) {
+			s.Info("Dropping write to deleted namespace", "namespace", col.Namespace, "key", col.Key)
+		} else if sibling {
+			s.addSibling(k, len(s.events)-1)
+		} else {
+			s.setLatest(k, len(s.events)-1)
+		}
 		s.byid[col.Clock.ID.String()] = len(s.events) - 1
+		s.logChange(ChangeReplicate, host, len(s.events)-1, col)
+		s.traceReplicated(ctx, host, col)
 		updated = append(updated, col)
 	}
 	return updated
 }
 
-func (s *Server) lookup(key string) (Column, bool) {
-	idx, ok := s.latest[key]
-	if !ok {
+// lookup returns the current version of k unless it expired or was deleted.
+func (s *Server) lookup(k nskey) (Column, bool) {
+	col, ok := s.version(k)
+	if !ok || col.Deleted {
+		return Column{}, false
+	}
+	return col, true
+}
+
+// version returns the current version of k, which may be a tombstone.
+func (s *Server) version(k nskey) (Column, bool) {
+	idx, ok := s.latest[k]
+	if !ok || s.events[idx].expired(time.Now()) {
 		return Column{}, false
 	}
 	return s.events[idx], true
 }
 
+// lookupAt finds the newest version of key whose origin clock is at most at.
+// Every replica returns contexts at least the origin clocks of what it read,
+// so a cut from any replica selects the same versions on all of them.
+func (s *Server) lookupAt(k nskey, at VectorClock) (Column, bool) {
+	now := time.Now()
+	for i := len(s.events) - 1; i >= 0; i-- {
+		col := s.events[i]
+		if col.nskey() == k && col.Clock.origin().AtMost(at) && !col.expired(now) {
+			return col, !col.Deleted
+		}
+	}
+	return Column{}, false
+}
+
+// setLatest makes the column at idx the only current version of k.
+func (s *Server) setLatest(k nskey, idx int) {
+	s.latest[k] = idx
+	delete(s.siblings, k)
+	s.reindex(k)
+}
+
+// addSibling adds the column at idx as a concurrent version of k. The
+// sibling with the newest timestamp is used as the value of k.
+func (s *Server) addSibling(k nskey, idx int) {
+	siblings := s.siblings[k]
+	if len(siblings) == 0 {
+		siblings = []int{s.latest[k]}
+	}
+	s.siblings[k] = append(siblings, idx)
+	if s.events[idx].Timestamp.After(s.events[s.latest[k]].Timestamp) {
+		s.latest[k] = idx
+	}
+	s.reindex(k)
+}
+
+// Ack acknowledges a column by ID instead of sending it back whole.
+type Ack struct {
+	ID uuid.UUID
+	// Seq is the sender's entry in its context of the column, which is the
+	// position of the column in the sender's log.
+	Seq        int `json:",omitempty"`
+	Replicated []string
+}
+
+// ackOf returns the ack of col by replica.
+func ackOf(col Column, replica string) Ack {
+	return Ack{
+		ID:         col.Clock.ID,
+		Seq:        col.Clock.Context[replica],
+		Replicated: setToPB(col.Clock.Replicated),
+	}
+}
+
+// acksOf returns our acks of cols.
+func (s *Server) acksOf(cols []Column) []Ack {
+	if len(cols) == 0 {
+		return nil
+	}
+	acks := make([]Ack, len(cols))
+	for i, col := range cols {
+		acks[i] = ackOf(col, s.Name)
+	}
+	return acks
+}
+
+// playAcks updates the replication data of the acked columns.
+// playAcks assumes the write lock is held.
+func (s *Server) playAcks(host string, acks []Ack) {
+	for _, a := range acks {
+		s.ack(host, a)
+	}
+}
+
+// ack updates the replication data of a column from an ack by host. It
+// returns the column and whether anything changed.
+// ack assumes the write lock is held.
+func (s *Server) ack(host string, a Ack) (Column, bool) {
+	existing, ok := s.lookupID(a.ID)
+	if !ok {
+		s.Info("Skipping ack of unknown event", "id", a.ID)
+		return Column{}, false
+	}
+	s.learn(host, existing.Clock.Context)
+	s.learn(host, VectorClock{host: a.Seq})
+	// The sender's context counts the events it logged before this one,
+	// which we may not have seen. Only when this event is the next one the
+	// sender logged can we count it as witnessed.
+	next := a.Seq == s.maxcc[host]+1
+	fresh := false
+	for _, replica := range a.Replicated {
+		if _, ok := existing.Clock.Replicated[replica]; !ok {
+			fresh = true
+		}
+	}
+	if !next && !fresh {
+		s.Info("Skipping prev. acked", "key", existing.Key)
+		return existing, false
+	}
+
+	s.Info("Updating replication metadata", "key", existing.Key)
+	if next {
+		s.maxcc[host]++
+	}
+	for _, replica := range a.Replicated {
+		existing.Clock.Replicated[replica] = nothing{}
+	}
+	s.logChange(ChangeAck, host, s.byid[a.ID.String()], existing)
+	return existing, true
+}
+
 func (s *Server) lookupID(id uuid.UUID) (Column, bool) {
 	idx, ok := s.byid[id.String()]
 	if !ok {
@@ -413,18 +1275,22 @@ func (s *Server) lookupID(id uuid.UUID) (Column, bool) {
 	return s.events[idx], true
 }
 
-func (s *Server) JSONRequest(method string, addr string, input any, output any) error {
+func (s *Server) JSONRequest(ctx context.Context, method string, addr string, input any, output any) error {
 	var body bytes.Buffer
 	if err := json.NewEncoder(&body).Encode(input); err != nil {
 		return err
 	}
 
-	httpreq, err := http.NewRequest(method, addr, &body)
+	httpreq, err := http.NewRequestWithContext(ctx, method, addr, bytes.NewReader(body.Bytes()))
 	if err != nil {
 		return err
 	}
 	httpreq.Header.Set("User-Agent", s.Name)
 	httpreq.Header.Set("Content-Type", "application/json")
+	injectTrace(ctx, httpreq)
+	if err := s.signPeer(httpreq, body.Bytes()); err != nil {
+		return err
+	}
 	httpresp, err := s.Client.Do(httpreq)
 	if err != nil {
 		return err
@@ -444,6 +1310,24 @@ func (cc *CausalClock) Merge(other CausalClock) {
 	}
 }
 
+func (cc CausalClock) Clone() CausalClock {
+	return CausalClock{
+		ID:         cc.ID,
+		Context:    cc.Context.Clone(),
+		Replicated: maps.Clone(cc.Replicated),
+		Origin:     cc.Origin.Clone(),
+	}
+}
+
+// origin returns the clock of the column on its origin. Columns from replicas
+// that predate Origin only have their context.
+func (cc CausalClock) origin() VectorClock {
+	if cc.Origin == nil {
+		return cc.Context
+	}
+	return cc.Origin
+}
+
 func (cc *CausalClock) Equal(other CausalClock) bool {
 	return cc.ID == other.ID && maps.Equal(cc.Context, other.Context) && maps.Equal(cc.Replicated, cc.Replicated)
 }
@@ -460,16 +1344,21 @@ func (s *Server) indexNotAcked(remote string) int {
 	return s.acked[remote]
 }
 
-func (s *Server) unreplicated(remote string) []Column {
+// unreplicated returns copies of up to limit columns that remote has not
+// acknowledged, and whether there are more.
+// unreplicated assumes the write lock is held.
+func (s *Server) unreplicated(remote string, limit int) (result []Column, more bool) {
 	startIdx := s.indexNotAcked(remote)
-	var result []Column
 	for i := startIdx; i < len(s.events); i++ {
 		if _, acked := s.events[i].Clock.Replicated[remote]; acked {
 			// It's possible there is a block of acknowledged events
 			// between unacked events, which we can skip.
 			continue
 		}
-		result = append(result, s.events[i])
+		if len(result) == limit {
+			return result, true
+		}
+		result = append(result, s.events[i].clone())
 	}
-	return result
+	return result, false
 }
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// CertReloader serves a certificate and key from files. Reload loads them
+// again, and Watch does so whenever either file changes.
+type CertReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the certificate and key from their files. If they cannot be
+// loaded the previous certificate is kept.
+func (r *CertReloader) Reload() error {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// Watch checks the files every freq until ctx is done, and reloads them if
+// either changed. A change that cannot be loaded, such as a certificate
+// written before its key, is tried again at the next check.
+func (r *CertReloader) Watch(ctx context.Context, freq time.Duration) {
+	ticker := time.NewTicker(freq)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.mu.Lock()
+		modTime := r.modTime
+		r.mu.Unlock()
+		if latest, err := r.lastModified(); err == nil && latest.After(modTime) {
+			_ = r.Reload()
+		}
+	}
+}
+
+func (r *CertReloader) lastModified() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// certificate returns the last certificate loaded.
+func (r *CertReloader) certificate() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cert, nil
+}
+
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.certificate()
+}
+
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.certificate()
+}
+
+// LoadCertPool reads a pool of PEM encoded CA certificates.
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(buf) {
+		return nil, fmt.Errorf("no certificates in %s", file)
+	}
+	return pool, nil
+}
+
+// ServerTLSConfig serves certs and verifies the client certificates that are
+// given against cas. Clients need not give one; peers are checked by the
+// endpoints that require them.
+func ServerTLSConfig(certs *CertReloader, cas *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+		ClientAuth:     tls.VerifyClientCertIfGiven,
+		ClientCAs:      cas,
+	}
+}
+
+// ClientTLSConfig presents certs to peers and verifies them against cas.
+func ClientTLSConfig(certs *CertReloader, cas *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		GetClientCertificate: certs.GetClientCertificate,
+		RootCAs:              cas,
+	}
+}
+
+// authorizePeer checks that a verified client certificate names a host in
+// the membership view. A node without a view yet accepts only a host in
+// replicas, the view it is being sent.
+func (s *Server) authorizePeer(state *tls.ConnectionState, replicas []string) error {
+	hosts := s.peerHosts()
+	if len(hosts) == 0 {
+		hosts = replicaHosts(replicas)
+	}
+	return s.authorizeHosts(state, hosts)
+}
+
+// authorizeJoin checks that a verified client certificate names a host in
+// the view or in replicas, the ones a node asks to join with.
+func (s *Server) authorizeJoin(state *tls.ConnectionState, replicas []string) error {
+	return s.authorizeHosts(state, append(s.peerHosts(), replicaHosts(replicas)...))
+}
+
+// authorizeHosts checks that a verified client certificate names one of
+// hosts. Without PeerAuth anyone is allowed.
+func (s *Server) authorizeHosts(state *tls.ConnectionState, hosts []string) error {
+	if !s.PeerAuth {
+		return nil
+	}
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return newerr(http.StatusUnauthorized, fmt.Errorf("peer certificate required"))
+	}
+	cert := state.VerifiedChains[0][0]
+	for _, host := range hosts {
+		if cert.VerifyHostname(host) == nil {
+			return nil
+		}
+	}
+	return newerr(http.StatusForbidden, fmt.Errorf("%q is not in the view", cert.Subject.CommonName))
+}
+
+// peerHosts returns the hosts of the peers in the view.
+func (s *Server) peerHosts() []string {
+	var hosts []string
+	for _, peer := range s.peerList() {
+		hosts = append(hosts, peer.Hostname())
+	}
+	return hosts
+}
+
+// replicaHosts returns the hosts of replica URLs, skipping invalid ones.
+func replicaHosts(replicas []string) []string {
+	var hosts []string
+	for _, replica := range replicas {
+		if addr, err := url.Parse(replica); err == nil {
+			hosts = append(hosts, addr.Hostname())
+		}
+	}
+	return hosts
+}
+
+// peerOnly serves h to peers that pass authorizePeer.
+func (s *Server) peerOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.authorizePeer(r.TLS, nil); err != nil {
+			s.Warn("Rejecting peer", "path", r.URL.Path, "addr", r.RemoteAddr, "err", err)
+			writeError(w, err)
+			return
+		}
+		h(w, r)
+	}
+}
+
+type connStateKey struct{}
+
+// withConnState puts the TLS state of a request in its context, for handlers
+// that authorize peers once they have read the request.
+func withConnState(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, r.WithContext(context.WithValue(r.Context(), connStateKey{}, r.TLS)))
+	}
+}
+
+// connState returns the TLS state of the request or RPC that ctx belongs to.
+func connState(ctx context.Context) *tls.ConnectionState {
+	if state, ok := ctx.Value(connStateKey{}).(*tls.ConnectionState); ok {
+		return state
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			return &info.State
+		}
+	}
+	return nil
+}
+
+// authorizeViewChange checks who may send a view change. Changes forwarded by
+// their origin must come from a peer. Admins may originate them through the
+// ACL; without one, only peers may.
+func (s *Server) authorizeViewChange(ctx context.Context, in ViewChange) error {
+	if !in.DoNotForward && s.ACL != nil {
+		return nil
+	}
+	return s.authorizePeer(connState(ctx), in.Replicas)
+}
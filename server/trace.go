@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// tracerName names the tracer of the server's spans.
+const tracerName = "github.com/spencer-p/okayv/server"
+
+// propagator carries trace context in W3C traceparent and tracestate headers,
+// in gRPC metadata and in the Trace of columns.
+var propagator = propagation.TraceContext{}
+
+// traceparent returns the W3C traceparent of the span in ctx, or an empty
+// string if there is none.
+func traceparent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// columnContext returns ctx with the span that wrote col as its remote parent.
+func columnContext(ctx context.Context, col Column) context.Context {
+	if col.Trace == "" {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier{"traceparent": col.Trace})
+}
+
+// traceReplicated records the replication of col from host as a span in the
+// trace that wrote it, linked to the gossip in ctx.
+func (s *Server) traceReplicated(ctx context.Context, host string, col Column) {
+	if col.Trace == "" {
+		return
+	}
+	_, span := s.tracer.Start(columnContext(ctx, col), "replicate",
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(
+			attribute.String("okayv.key", col.Key),
+			attribute.String("okayv.from", host),
+			attribute.String("okayv.replica", s.Name),
+		))
+	span.End()
+}
+
+// traceRequest starts a server span for a request, continuing the trace in its
+// headers.
+func (s *Server) traceRequest(r *http.Request, route string) (*http.Request, trace.Span) {
+	ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := s.tracer.Start(ctx, route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("okayv.replica", s.Name)))
+	return r.WithContext(ctx), span
+}
+
+// endRequest ends the span of a request with its status code.
+func endRequest(span trace.Span, code int) {
+	span.SetAttributes(attribute.Int("http.response.status_code", code))
+	if code >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(code))
+	}
+	span.End()
+}
+
+// injectTrace adds the trace context of ctx to the headers of a request.
+func injectTrace(ctx context.Context, r *http.Request) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+}
+
+// traceClientInterceptor adds the trace context of RPCs to their metadata.
+func traceClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	for k, v := range carrier {
+		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// traceInterceptor starts a server span for RPCs, continuing the trace in
+// their metadata.
+func (s *Server) traceInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	carrier := propagation.MapCarrier{}
+	for _, k := range propagator.Fields() {
+		if v := md.Get(k); len(v) > 0 {
+			carrier.Set(k, v[0])
+		}
+	}
+	ctx, span := s.tracer.Start(propagator.Extract(ctx, carrier), info.FullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("okayv.replica", s.Name)))
+	defer span.End()
+	resp, err := handler(ctx, req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return resp, err
+}
@@ -0,0 +1,44 @@
+package server
+
+import "time"
+
+// Tunables are the options that may change while the server runs.
+type Tunables struct {
+	GossipFreq   time.Duration
+	GossipFanout int
+	GossipBatch  int
+	PeerPolicy   PeerPolicy
+	MaxLag       time.Duration
+}
+
+// Tune replaces the tunable options. Zero values take the defaults of
+// NewServer, except that a zero GossipFreq is left unchanged. The gossip
+// frequency applies from the next round of RunBackground.
+func (s *Server) Tune(t Tunables) {
+	if t.GossipFanout == 0 {
+		t.GossipFanout = 1
+	}
+	if t.GossipBatch == 0 {
+		t.GossipBatch = DefaultGossipBatch
+	}
+	if t.PeerPolicy == "" {
+		t.PeerPolicy = PeerRandom
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if t.GossipFreq > 0 {
+		s.GossipFreq = t.GossipFreq
+	}
+	s.GossipFanout = t.GossipFanout
+	s.GossipBatch = t.GossipBatch
+	s.PeerPolicy = t.PeerPolicy
+	s.MaxLag = t.MaxLag
+	s.Info("Tuned", "freq", s.GossipFreq, "fanout", s.GossipFanout, "batch", s.GossipBatch, "policy", s.PeerPolicy, "maxlag", s.MaxLag)
+}
+
+// gossipFreq returns the current gossip frequency.
+func (s *Server) gossipFreq() time.Duration {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.GossipFreq
+}
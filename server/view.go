@@ -0,0 +1,170 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spencer-p/okayv/auth"
+)
+
+// maxAuditEntries is the most audit entries kept in memory.
+const maxAuditEntries = 1024
+
+// originate makes this node the origin of a view change sent by principal,
+// signing it with the ViewKey if there is one.
+func (s *Server) originate(principal string, in ViewChange) ViewChange {
+	in.Origin = s.Name
+	in.Principal = principal
+	in.Issued = time.Now().UTC()
+	in.Signature = nil
+	if s.ViewKey != nil {
+		in.Signature = ed25519.Sign(s.ViewKey, in.signed())
+	}
+	return in
+}
+
+// signed returns the bytes of a view change its origin signs.
+func (in ViewChange) signed() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "okayv view change\n%s\n%s\n%s\n", in.Origin, in.Principal, in.Issued.UTC().Format(time.RFC3339Nano))
+	for _, replica := range in.Replicas {
+		b.WriteString(replica)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+// verifyViewChange checks that a forwarded view change was signed by its
+// origin, is recent, and is newer than the last change from that origin.
+// Without ViewKeys forwarded changes are trusted.
+func (s *Server) verifyViewChange(in ViewChange) error {
+	if !in.DoNotForward || len(s.ViewKeys) == 0 {
+		return nil
+	}
+	key, ok := s.ViewKeys[in.Origin]
+	if !ok {
+		return newerr(http.StatusForbidden, fmt.Errorf("view change from unknown origin %q", in.Origin))
+	}
+	if !ed25519.Verify(key, in.signed(), in.Signature) {
+		return newerr(http.StatusForbidden, fmt.Errorf("view change from %q has a bad signature", in.Origin))
+	}
+	if age := time.Since(in.Issued); age > auth.DefaultMaxSkew || age < -auth.DefaultMaxSkew {
+		return newerr(http.StatusForbidden, fmt.Errorf("view change from %q was issued %s ago", in.Origin, age))
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !in.Issued.After(s.lastView[in.Origin]) {
+		return newerr(http.StatusConflict, fmt.Errorf("view change from %q issued at %s is a replay", in.Origin, in.Issued))
+	}
+	s.lastView[in.Origin] = in.Issued
+	return nil
+}
+
+// AuditEntry records a view change received by a node.
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	Node string    `json:"node"`
+	// Sender is the principal that sent the change to Node, and Principal
+	// the one that sent it to its Origin.
+	Sender    string   `json:"sender"`
+	Principal string   `json:"principal"`
+	Origin    string   `json:"origin"`
+	Replicas  []string `json:"replicas"`
+	Signed    bool     `json:"signed"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// audit records a view change and whether it was applied, writing it as a
+// JSON line to the AuditLog.
+func (s *Server) audit(sender string, in ViewChange, err error) {
+	entry := AuditEntry{
+		Time:      time.Now().UTC(),
+		Node:      s.Name,
+		Sender:    sender,
+		Principal: in.Principal,
+		Origin:    in.Origin,
+		Replicas:  in.Replicas,
+		Signed:    len(in.Signature) > 0,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		s.Warn("Rejected view change", "sender", sender, "origin", in.Origin, "err", err)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.auditLog = append(s.auditLog, entry)
+	if len(s.auditLog) > maxAuditEntries {
+		s.auditLog = s.auditLog[len(s.auditLog)-maxAuditEntries:]
+	}
+	if s.AuditLog != nil {
+		if err := json.NewEncoder(s.AuditLog).Encode(entry); err != nil {
+			s.Error("Cannot write audit log", "err", err)
+		}
+	}
+}
+
+// AuditEntries returns the most recent view changes the node received.
+func (s *Server) AuditEntries() []AuditEntry {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	out := make([]AuditEntry, len(s.auditLog))
+	copy(out, s.auditLog)
+	return out
+}
+
+func (s *Server) auditEntries(struct{}) ([]AuditEntry, error) {
+	return s.AuditEntries(), nil
+}
+
+// LoadViewKey reads a PEM encoded PKCS #8 ed25519 private key.
+func LoadViewKey(file string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T is not an ed25519 key", file, key)
+	}
+	return priv, nil
+}
+
+// LoadViewPublicKey reads a PEM encoded PKIX ed25519 public key.
+func LoadViewPublicKey(file string) (ed25519.PublicKey, error) {
+	der, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T is not an ed25519 key", file, key)
+	}
+	return pub, nil
+}
+
+func readPEM(file string) ([]byte, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(buf)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", file)
+	}
+	return block.Bytes, nil
+}
@@ -9,6 +9,13 @@ type ReadResult struct {
 	NotFound     bool
 }
 
+// ReadManyResult is the result of reading several keys from one causal cut.
+type ReadManyResult struct {
+	Client, Node string
+	Reads        []ReadResult
+	Error        bool
+}
+
 type treenode struct {
 	key, value string
 	deleted    bool
@@ -62,87 +69,147 @@ func ValidateCausality(actions []any) error {
 				// Shrug.
 				continue
 			}
-			err := func() error {
-				var considered []*treenode
-				for _, cursor := range cursors[v.Client] {
-					// Find immediate instances of Key and see if they have Value.
-					// If not found allowed to traverse disconnected trees
-					candidates := searchhistory(v.Key, cursor, true /*happenedbefore*/)
-					for _, c := range candidates {
-						if c.value == v.Value ||
-							(c.deleted && v.NotFound) {
-							// Valid read in prior history.
-							return nil
-						}
-					}
-					// If we couldn't find anything in prior history and we got
-					// a 404, that's OK. This may be a lagging replica.
-					if v.NotFound && len(candidates) == 0 {
-						return nil
-					}
-					considered = append(considered, candidates...)
-
-					// Find candidates in the "future".
-					candidates = searchhistory(v.Key, cursor, false /*happenedafter*/)
-					for _, c := range candidates {
-						if c.value == v.Value ||
-							(c.deleted && v.NotFound) {
-							// Valid read from the future.
-							// This cursor has now advanced.
-							cursors[v.Client] = append(cursors[v.Client], c)
-							//cursors[v.Client][idx] = c
-							return nil
-						}
-					}
-					considered = append(considered, candidates...)
-				}
-				// If no cursor for the client could see a value and we got a
-				// 404, then that's valid.
-				if v.NotFound && len(considered) == 0 {
-					return nil
-				}
-				// Start descending roots to add a new cursor.
-				for _, root := range roots {
-					independent, ok := searchunrelated(v.Key, v.Value, root, cursors[v.Client])
-					if ok {
-						// Valid independent read.
-						// Creates new cursor
-						cursors[v.Client] = append(cursors[v.Client], independent)
-						return nil
-					}
-				}
-				wantedvals := []string{}
-				for _, c := range considered {
-					wantedvals = append(wantedvals, c.value)
-				}
+			if _, err := validateRead(v, actioni, roots, cursors); err != nil {
+				return err
+			}
+			prunecursors(cursors, v.Client)
+		case ReadManyResult:
+			if v.Error {
+				continue
+			}
+			if err := validateReadMany(v, actioni, roots, cursors); err != nil {
+				return err
+			}
+		default:
+			panic(fmt.Sprintf("type error: invalid action type %T", a))
+		}
+	}
+	return nil
+}
+
+// validateRead checks that a single read is causally valid for its client and
+// advances the client's cursors. It returns the write that was observed, or
+// nil if the read was a valid 404.
+func validateRead(v ReadResult, actioni int, roots map[string]*treenode, cursors map[string][]*treenode) (*treenode, error) {
+	var considered []*treenode
+	for _, cursor := range cursors[v.Client] {
+		// Find immediate instances of Key and see if they have Value.
+		// If not found allowed to traverse disconnected trees
+		candidates := searchhistory(v.Key, cursor, true /*happenedbefore*/)
+		for _, c := range candidates {
+			if c.value == v.Value ||
+				(c.deleted && v.NotFound) {
+				// Valid read in prior history.
+				return c, nil
+			}
+		}
+		// If we couldn't find anything in prior history and we got
+		// a 404, that's OK. This may be a lagging replica.
+		if v.NotFound && len(candidates) == 0 {
+			return nil, nil
+		}
+		considered = append(considered, candidates...)
+
+		// Find candidates in the "future".
+		candidates = searchhistory(v.Key, cursor, false /*happenedafter*/)
+		for _, c := range candidates {
+			if c.value == v.Value ||
+				(c.deleted && v.NotFound) {
+				// Valid read from the future.
+				// This cursor has now advanced.
+				cursors[v.Client] = append(cursors[v.Client], c)
+				//cursors[v.Client][idx] = c
+				return c, nil
+			}
+		}
+		considered = append(considered, candidates...)
+	}
+	// If no cursor for the client could see a value and we got a
+	// 404, then that's valid.
+	if v.NotFound && len(considered) == 0 {
+		return nil, nil
+	}
+	// Start descending roots to add a new cursor.
+	for _, root := range roots {
+		independent, ok := searchunrelated(v.Key, v.Value, root, cursors[v.Client])
+		if ok {
+			// Valid independent read.
+			// Creates new cursor
+			cursors[v.Client] = append(cursors[v.Client], independent)
+			return independent, nil
+		}
+	}
+	wantedvals := []string{}
+	for _, c := range considered {
+		wantedvals = append(wantedvals, c.value)
+	}
+	return nil, CausalError{
+		error:   fmt.Errorf("%s cannot read %s=%s at index %d, wanted %v", v.Client, v.Key, v.Value, actioni, wantedvals),
+		Roots:   roots,
+		Cursors: cursors,
+	}
+}
+
+// validateReadMany checks each read of a multi-read on its own, then checks
+// that the values are mutually consistent: no value may be older than a write
+// of the same key that another value in the result causally depends on.
+func validateReadMany(v ReadManyResult, actioni int, roots map[string]*treenode, cursors map[string][]*treenode) error {
+	observed := make([]*treenode, len(v.Reads))
+	for i, read := range v.Reads {
+		n, err := validateRead(read, actioni, roots, cursors)
+		if err != nil {
+			return err
+		}
+		prunecursors(cursors, v.Client)
+		observed[i] = n
+	}
+
+	for j, nj := range observed {
+		if nj == nil {
+			continue
+		}
+		for i, read := range v.Reads {
+			if i == j || read.Key == v.Reads[j].Key {
+				continue
+			}
+			// The nearest writes of this key that the other value depends on.
+			seen := searchhistory(read.Key, nj, true /*happenedbefore*/)
+			if read.NotFound && len(seen) > 0 {
 				return CausalError{
-					error:   fmt.Errorf("%s cannot read %s=%s at index %d, wanted %v", v.Client, v.Key, v.Value, actioni, wantedvals),
+					error:   fmt.Errorf("%s read %s=notfound with %s=%s at index %d, which depends on a write of %s", v.Client, read.Key, v.Reads[j].Key, v.Reads[j].Value, actioni, read.Key),
 					Roots:   roots,
 					Cursors: cursors,
 				}
-			}()
-			if err != nil {
-				return err
 			}
-			// Try to drop any redundant cursors superseded by the cursor that
-			// may have been added..
-			if len(cursors[v.Client]) > 0 {
-				var newCursors []*treenode
-				lastCursor := cursors[v.Client][len(cursors[v.Client])-1]
-				for i := 0; i+1 < len(cursors[v.Client]); i++ {
-					if !relateddir(lastCursor, cursors[v.Client][i], happenedbefore) {
-						newCursors = append(newCursors, cursors[v.Client][i])
+			for _, c := range seen {
+				if c != observed[i] && observed[i] != nil && relateddir(observed[i], c, happenedafter) {
+					return CausalError{
+						error:   fmt.Errorf("%s read %s=%s with %s=%s at index %d, which depends on %s=%s", v.Client, read.Key, read.Value, v.Reads[j].Key, v.Reads[j].Value, actioni, c.key, c.value),
+						Roots:   roots,
+						Cursors: cursors,
 					}
 				}
-				cursors[v.Client] = append(newCursors, lastCursor)
 			}
-		default:
-			panic(fmt.Sprintf("type error: invalid action type %T", a))
 		}
 	}
 	return nil
 }
 
+// prunecursors drops any redundant cursors superseded by the cursor that may
+// have been added last.
+func prunecursors(cursors map[string][]*treenode, client string) {
+	if len(cursors[client]) > 0 {
+		var newCursors []*treenode
+		lastCursor := cursors[client][len(cursors[client])-1]
+		for i := 0; i+1 < len(cursors[client]); i++ {
+			if !relateddir(lastCursor, cursors[client][i], happenedbefore) {
+				newCursors = append(newCursors, cursors[client][i])
+			}
+		}
+		cursors[client] = append(newCursors, lastCursor)
+	}
+}
+
 // searchhistory searches history starting from roots for any reachable matching
 // keys. The paths from root to matching key never contain the key itself.
 func searchhistory(key string, root *treenode, before bool) []*treenode {
@@ -220,6 +220,46 @@ func TestValidate(t *testing.T) {
 			r("c1 from n1: x=2"),
 		},
 		valid: true,
+	}, {
+		name: "read many, consistent",
+		actions: []any{
+			w("alice to a: x=1"),
+			w("alice to a: y=2"),
+			rm(r("bob from a: x=1"), r("bob from a: y=2")),
+		},
+		valid: true,
+	}, {
+		name: "read many, stale value",
+		actions: []any{
+			w("alice to a: x=1"),
+			w("alice to a: x=2"),
+			w("alice to a: y=3"),
+			rm(r("bob from a: x=1"), r("bob from a: y=3")),
+		},
+		valid: false,
+	}, {
+		name: "read many, missing dependency",
+		actions: []any{
+			w("alice to a: x=1"),
+			w("alice to a: y=2"),
+			rm(r("bob from a: x=notfound"), r("bob from a: y=2")),
+		},
+		valid: false,
+	}, {
+		name: "read many, unrelated writes",
+		actions: []any{
+			w("alice to a: x=1"),
+			w("bob to b: y=2"),
+			rm(r("carol from a: x=1"), r("carol from a: y=notfound")),
+		},
+		valid: true,
+	}, {
+		name: "read many, unavailable",
+		actions: []any{
+			w("alice to a: x=1"),
+			ReadManyResult{Client: "bob", Node: "a", Error: true},
+		},
+		valid: true,
 	}}
 
 	for _, tc := range table {
@@ -296,6 +336,14 @@ func r(s string) ReadResult {
 	}
 }
 
+func rm(reads ...ReadResult) ReadManyResult {
+	return ReadManyResult{
+		Client: reads[0].Client,
+		Node:   reads[0].Node,
+		Reads:  reads,
+	}
+}
+
 func printTree(t *testing.T, depth int, node *treenode) {
 	s := ""
 	for i := 0; i < depth; i++ {
@@ -11,6 +11,8 @@ const (
 	iRead
 	iConnect
 	iPartition
+	iReadMany
+	iIncrement
 )
 
 func Parse(input []byte) ([]Instr, error) {
@@ -22,6 +24,8 @@ func Parse(input []byte) ([]Instr, error) {
 		iPartition: parsePartition,
 		iWrite:     parseWrite,
 		iRead:      parseRead,
+		iReadMany:  parseReadMany,
+		iIncrement: parseIncrement,
 	}
 
 	for len(input) > 0 {
@@ -103,6 +107,49 @@ func parseRead(in []byte) (Instr, int, error) {
 	}, 3, nil
 }
 
+func parseReadMany(in []byte) (Instr, int, error) {
+	if len(in) < 3 {
+		return nil, 0, fmt.Errorf("missing three bytes for read many instruction")
+	}
+	clientindex := in[0]
+	if int(clientindex) >= len(clientNames) {
+		return nil, 0, fmt.Errorf("cannot name client with %d, sorry", clientindex)
+	}
+	n := int(in[2])
+	if n == 0 {
+		return nil, 0, fmt.Errorf("read many instruction needs at least one key")
+	}
+	if len(in) < 3+n {
+		return nil, 0, fmt.Errorf("missing %d keys for read many instruction", n)
+	}
+	var keys []string
+	for _, key := range in[3 : 3+n] {
+		keys = append(keys, fmt.Sprintf("%02x", key))
+	}
+	return ReadMany{
+		Client: clientNames[clientindex],
+		Node:   nodeName(in[1]),
+		Keys:   keys,
+	}, 3 + n, nil
+}
+
+func parseIncrement(in []byte) (Instr, int, error) {
+	if len(in) < 4 {
+		return nil, 0, fmt.Errorf("missing four bytes for increment instruction")
+	}
+	clientindex := in[0]
+	if int(clientindex) >= len(clientNames) {
+		return nil, 0, fmt.Errorf("cannot name client with %d, sorry", clientindex)
+	}
+	return Increment{
+		Client: clientNames[clientindex],
+		Node:   nodeName(in[1]),
+		// Counters have their own keys so that they never hold plain values.
+		Key:   fmt.Sprintf("c%02x", in[2]),
+		Delta: int64(int8(in[3])),
+	}, 4, nil
+}
+
 func nodeName(b byte) string {
 	return fmt.Sprintf("node_%02x", b)
 }
@@ -124,6 +171,14 @@ func validateProgram(program []Instr) error {
 			if _, ok := nodes[v.Node]; !ok {
 				return fmt.Errorf("instruction %d: node does not exist", idx)
 			}
+		case ReadMany:
+			if _, ok := nodes[v.Node]; !ok {
+				return fmt.Errorf("instruction %d: node does not exist", idx)
+			}
+		case Increment:
+			if _, ok := nodes[v.Node]; !ok {
+				return fmt.Errorf("instruction %d: node does not exist", idx)
+			}
 		case Connect:
 			if v.A == v.B {
 				return fmt.Errorf("node cannot partition itself")
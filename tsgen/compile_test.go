@@ -7,13 +7,21 @@ func TestParse(t *testing.T) {
 		0, 1, // Register node 1.
 		1, 0, 1, 9, 9, // Alice writes 9=9 to node 1.
 		2, 0, 1, 9, // Alice reads 9 from node 1.
+		5, 0, 1, 2, 9, 8, // Alice reads 9 and 8 from node 1.
+		6, 0, 1, 9, 0xff, // Alice decrements counter 9 on node 1.
 	}
 
 	p, err := Parse(raw)
 	if err != nil {
 		t.Errorf("failed to parse: %v", err)
 	}
-	if len(p) != 3 {
-		t.Errorf("got %d instructions, wanted %d", len(p), 3)
+	if len(p) != 5 {
+		t.Fatalf("got %d instructions, wanted %d", len(p), 5)
+	}
+	if rm, ok := p[3].(ReadMany); !ok || len(rm.Keys) != 2 {
+		t.Errorf("got %#v, wanted a read of two keys", p[3])
+	}
+	if inc, ok := p[4].(Increment); !ok || inc.Key != "c09" || inc.Delta != -1 {
+		t.Errorf("got %#v, wanted a decrement of c09", p[4])
 	}
 }
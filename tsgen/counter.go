@@ -0,0 +1,34 @@
+package tsgen
+
+import "fmt"
+
+// CounterResult is the value of a counter read from a node after the cluster
+// has healed and gossiped.
+type CounterResult struct {
+	Node, Key string
+	Value     int64
+	NotFound  bool
+}
+
+// ValidateCounters checks that every node converged on the sum of the
+// successful increments of each counter. Increments that failed must not be
+// passed in.
+func ValidateCounters(increments []Increment, results []CounterResult) error {
+	want := map[string]int64{}
+	for _, inc := range increments {
+		want[inc.Key] += inc.Delta
+	}
+	for _, r := range results {
+		total, incremented := want[r.Key]
+		if r.NotFound {
+			if incremented {
+				return fmt.Errorf("%s does not have counter %s, wanted %d", r.Node, r.Key, total)
+			}
+			continue
+		}
+		if r.Value != total {
+			return fmt.Errorf("%s has counter %s=%d, wanted %d", r.Node, r.Key, r.Value, total)
+		}
+	}
+	return nil
+}
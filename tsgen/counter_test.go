@@ -0,0 +1,52 @@
+package tsgen
+
+import "testing"
+
+func TestValidateCounters(t *testing.T) {
+	increments := []Increment{
+		{Client: "alice", Node: "a", Key: "x", Delta: 3},
+		{Client: "bob", Node: "b", Key: "x", Delta: -1},
+		{Client: "alice", Node: "b", Key: "y", Delta: 1},
+	}
+	table := []struct {
+		name    string
+		results []CounterResult
+		valid   bool
+	}{{
+		name: "converged",
+		results: []CounterResult{
+			{Node: "a", Key: "x", Value: 2},
+			{Node: "b", Key: "x", Value: 2},
+			{Node: "a", Key: "y", Value: 1},
+		},
+		valid: true,
+	}, {
+		name: "lost increment",
+		results: []CounterResult{
+			{Node: "a", Key: "x", Value: 3},
+			{Node: "b", Key: "x", Value: 2},
+		},
+		valid: false,
+	}, {
+		name: "missing counter",
+		results: []CounterResult{
+			{Node: "a", Key: "y", NotFound: true},
+		},
+		valid: false,
+	}, {
+		name: "never incremented",
+		results: []CounterResult{
+			{Node: "a", Key: "z", NotFound: true},
+		},
+		valid: true,
+	}}
+
+	for _, tc := range table {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateCounters(increments, tc.results)
+			if got := err == nil; got != tc.valid {
+				t.Errorf("ValidateCounters returned %v, wanted valid=%t", err, tc.valid)
+			}
+		})
+	}
+}
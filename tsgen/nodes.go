@@ -29,6 +29,28 @@ func (r Read) Apply(m Model, i Impl) error {
 	return i.Read(r.Client, r.Node, r.Key)
 }
 
+type ReadMany struct {
+	Client string
+	Node   string
+	Keys   []string
+}
+
+func (r ReadMany) Apply(m Model, i Impl) error {
+	return i.ReadMany(r.Client, r.Node, r.Keys)
+}
+
+// Increment adds Delta to a counter.
+type Increment struct {
+	Client string
+	Node   string
+	Key    string
+	Delta  int64
+}
+
+func (inc Increment) Apply(m Model, i Impl) error {
+	return i.Increment(inc.Client, inc.Node, inc.Key, inc.Delta)
+}
+
 type Connect struct {
 	A, B string
 }
@@ -10,6 +10,8 @@ type Impl interface {
 	CreateNode(name string) error
 	Read(client, node, key string) error
 	Write(client, node, key, value string) error
+	ReadMany(client, node string, keys []string) error
+	Increment(client, node, key string, delta int64) error
 }
 
 type Model struct {